@@ -0,0 +1,208 @@
+package chess
+
+import (
+	"math/bits"
+)
+
+// bitboard represents a set of squares of the board, one bit per square.
+//
+// The bit index of a square is I*8 + J, so a8 is bit 0 and h1 is bit 63.
+type bitboard uint64
+
+func squareIndex(square Square) int {
+	return int(square.I)*8 + int(square.J)
+}
+
+func squareFromIndex(index int) Square {
+	return newSquare(uint8(index/8), uint8(index%8))
+}
+
+func bitboardOf(index int) bitboard {
+	return bitboard(1) << index
+}
+
+func (b bitboard) has(index int) bool {
+	return b&bitboardOf(index) != 0
+}
+
+func (b bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// first returns the index of the least significant square in the set.
+//
+// Note: It assumes that the set is not empty.
+func (b bitboard) first() int {
+	return bits.TrailingZeros64(uint64(b))
+}
+
+// Precomputed attacks of leaper pieces, for each square index
+var knightAttacks [64]bitboard
+var kingAttacks [64]bitboard
+var pawnAttacks [COLOR_AMOUNT + 1][64]bitboard
+
+// magicEntry contains the data needed to look up the attacks of a sliding piece
+// from a square, given the board occupancy, via magic multiplication.
+type magicEntry struct {
+	mask    bitboard // Relevant occupancy squares (the edges are excluded)
+	magic   uint64
+	shift   uint8
+	attacks []bitboard
+}
+
+var rookMagics [64]magicEntry
+var bishopMagics [64]magicEntry
+
+// Magic numbers that map every relevant occupancy of a square to its attacks
+// without destructive collisions. Found via trial and error with sparse
+// random numbers.
+var rookMagicNumbers = [64]uint64{
+	0x1080004008801020, 0x0840092002C03000, 0x1900200010400900, 0x0880100008000480,
+	0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+	0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+	0x000A001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+	0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021D00100,
+	0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000A0001768104,
+	0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+	0x0050500500080100, 0x0000020080040080, 0x0C10010400420810, 0x1040008200005104,
+	0x01808240088004A0, 0x0882804004802000, 0x0880402001001100, 0x2000210409001000,
+	0x2000480131001500, 0x0000800400800200, 0x000002380C001003, 0x4600084882000431,
+	0x0080002000504000, 0x0300500020004002, 0x0040408200220011, 0x0010040008004040,
+	0x0000080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+	0x0088403882010200, 0x0820400080210100, 0x0110910040A00300, 0x0801100280080480,
+	0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+	0x0000209300488001, 0x04C1002414824001, 0x020020000B001041, 0x7000100004200901,
+	0x8002002004100802, 0x30010002084C0007, 0x0888221800813004, 0x4000002840840112,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x20C0090901061081, 0x0024040094030104, 0x8210810200290200, 0x0011040484620000,
+	0x0081104002221000, 0x0009012011001350, 0x0081010802400380, 0x0000420210010408,
+	0x0008105002280050, 0x0001028484040044, 0x2A00880810408804, 0x7020022282000100,
+	0x0084040420100A50, 0x000401010840E000, 0x2020020210420888, 0x0008084202012010,
+	0x2010400810018800, 0x0445122008020840, 0x0804100808002008, 0x0008002104110100,
+	0x0061005820080800, 0x2001000200820100, 0x480C210084010800, 0x3004442500480420,
+	0x1010102240048100, 0x00182009084220A3, 0x8803090A10004205, 0x0208080040202020,
+	0x000C044084010040, 0x00A1010002004106, 0x6008210020640202, 0x1600902112860801,
+	0x00042008C1220200, 0x010C042002440140, 0x5022080200040820, 0x0402004042940100,
+	0x0860108400008020, 0x000C080022021000, 0x0264080652822100, 0x4005031221010401,
+	0x0004502410008400, 0x000500B010A20400, 0x0415094050080800, 0x080000201800A104,
+	0x4022A80304000110, 0x4012140802028020, 0x40200104010100A0, 0x12810806008B0C41,
+	0x0020441008080000, 0x2002120084045420, 0x0704020062080002, 0x0000001084040001,
+	0x0322200891240200, 0xF040200210024800, 0x0140824832008042, 0x000210020A004602,
+	0x0083042805141020, 0x002C12009A011000, 0x0041A00044140400, 0x00004004020A0202,
+	0x0000140010020210, 0x2864160811012200, 0x2060080841082A17, 0xA010041108003100,
+}
+
+func init() {
+	for index := 0; index < 64; index++ {
+		knightAttacks[index] = offsetAttacks(index, knightOffsets[:])
+		kingAttacks[index] = offsetAttacks(index, kingOffsets[:])
+		for _, color := range [2]Color{Color_White, Color_Black} {
+			offsets := pawnAttackOffsets[color]
+			pawnAttacks[color][index] = offsetAttacks(index, offsets[:])
+		}
+	}
+
+	for index := 0; index < 64; index++ {
+		rookMagics[index] = newMagicEntry(index, rookDirections, rookMagicNumbers[index])
+		bishopMagics[index] = newMagicEntry(index, bishopDirections, bishopMagicNumbers[index])
+	}
+}
+
+func offsetAttacks(index int, offsets [][2]int8) bitboard {
+	square := squareFromIndex(index)
+	var attacks bitboard
+
+	for _, offset := range offsets {
+		targetRow, targetCol := int8(square.I)+offset[0], int8(square.J)+offset[1]
+		if targetRow >= 0 && targetCol >= 0 && targetRow < 8 && targetCol < 8 {
+			attacks |= bitboardOf(int(targetRow)*8 + int(targetCol))
+		}
+	}
+
+	return attacks
+}
+
+// slidingAttacks computes the attacks of a sliding piece by walking each
+// direction until a piece is found. It is only used to build the magic tables.
+//
+// If isMask is set, the last square of each direction is excluded, as a piece
+// there can not block any other square.
+func slidingAttacks(index int, occupied bitboard, directions [4][2]int8, isMask bool) bitboard {
+	square := squareFromIndex(index)
+	var attacks bitboard
+
+	for _, dir := range directions {
+		i := int8(square.I) + dir[0]
+		j := int8(square.J) + dir[1]
+
+		for i >= 0 && j >= 0 && i < 8 && j < 8 {
+			if isMask {
+				nextI, nextJ := i+dir[0], j+dir[1]
+				if nextI < 0 || nextJ < 0 || nextI >= 8 || nextJ >= 8 {
+					break
+				}
+			}
+
+			target := int(i)*8 + int(j)
+			attacks |= bitboardOf(target)
+			if occupied.has(target) {
+				break
+			}
+
+			i += dir[0]
+			j += dir[1]
+		}
+	}
+
+	return attacks
+}
+
+// newMagicEntry builds the attack table of a sliding piece at the square
+// index, by enumerating all the subsets of its relevant occupancy mask.
+func newMagicEntry(index int, directions [4][2]int8, magic uint64) magicEntry {
+	mask := slidingAttacks(index, 0, directions, true)
+	bitCount := mask.count()
+
+	entry := magicEntry{
+		mask:    mask,
+		magic:   magic,
+		shift:   uint8(64 - bitCount),
+		attacks: make([]bitboard, 1<<bitCount),
+	}
+
+	// Carry-Rippler subset enumeration
+	occupied := bitboard(0)
+	for {
+		entry.attacks[entry.index(occupied)] = slidingAttacks(index, occupied, directions, false)
+
+		occupied = (occupied - mask) & mask
+		if occupied == 0 {
+			break
+		}
+	}
+
+	return entry
+}
+
+func (m *magicEntry) index(occupied bitboard) uint64 {
+	return (uint64(occupied&m.mask) * m.magic) >> m.shift
+}
+
+func rookAttacks(index int, occupied bitboard) bitboard {
+	entry := &rookMagics[index]
+	return entry.attacks[entry.index(occupied)]
+}
+
+func bishopAttacks(index int, occupied bitboard) bitboard {
+	entry := &bishopMagics[index]
+	return entry.attacks[entry.index(occupied)]
+}
+
+// rowSpan returns the squares from one square index to another of the same
+// row, both included.
+func rowSpan(fromIndex, toIndex int) bitboard {
+	low, high := min(fromIndex, toIndex), max(fromIndex, toIndex)
+	return (bitboardOf(high) - bitboardOf(low)) | bitboardOf(high)
+}
@@ -0,0 +1,19 @@
+package chess
+
+import "testing"
+
+func TestMagicAttacks(t *testing.T) {
+	// Some occupancies, to compare the magic lookups against the slow attacks
+	occupancies := []bitboard{0, 0x00FF00000000FF00, 0x0000241818240000, 0x8142241818244281, 0xFFFFFFFFFFFFFFFF}
+
+	for index := 0; index < 64; index++ {
+		for _, occupied := range occupancies {
+			if got, want := rookAttacks(index, occupied), slidingAttacks(index, occupied, rookDirections, false); got != want {
+				t.Errorf("Rook attacks at %s with occupancy %016x: got %016x, want %016x.", squareFromIndex(index).Algebraic(), uint64(occupied), uint64(got), uint64(want))
+			}
+			if got, want := bishopAttacks(index, occupied), slidingAttacks(index, occupied, bishopDirections, false); got != want {
+				t.Errorf("Bishop attacks at %s with occupancy %016x: got %016x, want %016x.", squareFromIndex(index).Algebraic(), uint64(occupied), uint64(got), uint64(want))
+			}
+		}
+	}
+}
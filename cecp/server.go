@@ -0,0 +1,615 @@
+// Package cecp implements the Chess Engine Communication Protocol (CECP,
+// also known as the XBoard/WinBoard protocol) version 2, to use a search
+// engine written in Go from XBoard compatible GUIs and tools.
+//
+// It mirrors the uci package's server, and searches with the same
+// uci.Searcher interface, so an engine can support both protocols.
+package cecp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/uci"
+)
+
+// Notation represents the notation of the movements sent by the engine.
+type Notation uint8
+
+const (
+	Notation_Coordinate Notation = iota // Pure algebraic coordinate notation, such as "e2e4" or "e7e8q"
+	Notation_SAN                        // Standard Algebraic Notation, such as "e4" or "e8=Q"
+)
+
+const startingFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// The time spent per movement when no time control was set.
+const defaultMoveTime = 5 * time.Second
+
+// Server represents the CECP front-end of a uci.Searcher.
+//
+// The options of searchers that implement uci.Configurable are sent as
+// CECP option features. Searchers that implement uci.NewGamer are told
+// about "new".
+//
+// Note: If you intend in creating a new Server, use NewServer() function.
+type Server struct {
+	name     string
+	searcher uci.Searcher
+	notation Notation
+
+	w       io.Writer
+	writeMu sync.Mutex
+
+	game        *chess.Game
+	isChess960  bool
+	engineColor chess.Color // Color_None in force mode
+	post        bool        // Whether to send the thinking output
+
+	// Time controls
+	movesPerSession int           // 0 for incremental or sudden death time controls
+	baseTime        time.Duration // Time per session
+	increment       time.Duration
+	moveTime        time.Duration // Exact time per movement, set via "st"
+	depth           int           // Maximum depth, set via "sd"
+	engineTime      time.Duration // Engine's remaining time, set via "time"
+	opponentTime    time.Duration // Opponent's remaining time, set via "otim"
+
+	search *serverSearch // The running search, if any
+}
+
+// serverSearch represents a search running in the background.
+type serverSearch struct {
+	cancel  context.CancelFunc
+	discard atomic.Bool   // Whether the movement must not be played once stopped
+	done    chan struct{} // Closed once the movement has been played or discarded
+}
+
+// NewServer creates and returns a new Server, identified with the passed
+// name, that searches with the passed Searcher. Movements are sent in
+// coordinate notation, unless changed via SetNotation().
+func NewServer(name string, searcher uci.Searcher) *Server {
+	game, _ := chess.NewGame(startingFen)
+	return &Server{
+		name:        name,
+		searcher:    searcher,
+		notation:    Notation_Coordinate,
+		game:        &game,
+		engineColor: chess.Color_Black,
+	}
+}
+
+// SetNotation sets the notation of the movements sent by the engine,
+// including the principal variations of the thinking output.
+func (s *Server) SetNotation(notation Notation) {
+	s.notation = notation
+}
+
+// Serve reads CECP commands from r, and writes the answers to w, until
+// "quit" is received or r ends. Searches run in the background, so "?",
+// "ping" and "result" are answered while thinking.
+//
+// Example:
+//
+//	server := cecp.NewServer("My Engine", searcher)
+//	server.Serve(os.Stdin, os.Stdout)
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.stopSearch(false)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		arguments := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+
+		var err error
+		switch fields[0] {
+		case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "name", "rating", "ics", "draw", "hint", "bk", ".":
+			// Not needed by the engine
+		case "protover":
+			s.sendFeatures()
+		case "ping":
+			s.send("pong " + arguments)
+		case "new":
+			s.stopSearch(true)
+			if newGamer, ok := s.searcher.(uci.NewGamer); ok {
+				newGamer.NewGame()
+			}
+			s.isChess960 = false
+			s.engineColor = chess.Color_Black
+			s.depth = 0
+			s.moveTime = 0
+			s.engineTime, s.opponentTime = s.baseTime, s.baseTime
+			err = s.setBoard(startingFen)
+		case "variant":
+			s.isChess960 = arguments == "fischerandom"
+			if !s.isChess960 && arguments != "normal" {
+				err = fmt.Errorf("The variant \"%s\" is not supported.", arguments)
+			}
+		case "setboard":
+			s.stopSearch(true)
+			err = s.setBoard(arguments)
+		case "force":
+			s.stopSearch(true)
+			s.engineColor = chess.Color_None
+		case "go":
+			s.stopSearch(true)
+			s.engineColor = s.game.CurrentPosition().Turn()
+			s.think()
+		case "playother":
+			s.stopSearch(true)
+			s.engineColor = s.game.CurrentPosition().Turn().Opposite()
+		case "?":
+			s.stopSearch(false)
+		case "usermove":
+			s.stopSearch(true)
+			err = s.userMove(arguments)
+		case "undo", "remove":
+			s.stopSearch(true)
+			err = s.game.UndoMovement()
+			if err == nil && fields[0] == "remove" {
+				err = s.game.UndoMovement()
+			}
+		case "result":
+			s.stopSearch(true)
+			s.engineColor = chess.Color_None
+			if outcome := resultOutcome(arguments); outcome != chess.Outcome_None && s.game.Outcome() == chess.Outcome_None {
+				s.game.Terminate(outcome)
+			}
+		case "level":
+			err = s.setLevel(fields[1:])
+		case "st":
+			var seconds float64
+			if seconds, err = strconv.ParseFloat(arguments, 64); err == nil {
+				s.moveTime = time.Duration(seconds * float64(time.Second))
+			}
+		case "sd":
+			s.depth, err = strconv.Atoi(arguments)
+		case "time", "otim":
+			var centiseconds int64
+			if centiseconds, err = strconv.ParseInt(arguments, 10, 64); err == nil {
+				if fields[0] == "time" {
+					s.engineTime = time.Duration(centiseconds) * 10 * time.Millisecond
+				} else {
+					s.opponentTime = time.Duration(centiseconds) * 10 * time.Millisecond
+				}
+			}
+		case "post", "nopost":
+			s.post = fields[0] == "post"
+		case "option":
+			err = s.setOption(arguments)
+		case "memory":
+			err = s.setOption("Hash=" + arguments)
+		case "quit":
+			return nil
+		default:
+			// Movements are sent without "usermove" if the feature was rejected.
+			// The search is stopped first, as it may be playing its movement.
+			s.stopSearch(true)
+			if _, parseErr := s.parseMovement(fields[0]); parseErr == nil {
+				err = s.userMove(fields[0])
+			} else if isCoordinateMovement(fields[0]) {
+				s.send("Illegal move: " + fields[0])
+			} else {
+				s.send("Error (unknown command): " + line)
+			}
+		}
+
+		if err != nil {
+			s.send("Error (" + strings.TrimSuffix(err.Error(), ".") + "): " + line)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Server) sendFeatures() {
+	var sb strings.Builder
+	sb.WriteString("feature ping=1 setboard=1 playother=1 usermove=1 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=0 colors=0")
+	sb.WriteString(" san=0 variants=\"normal,fischerandom\" myname=\"" + s.name + "\"")
+
+	if configurable, ok := s.searcher.(uci.Configurable); ok {
+		for _, option := range configurable.Options() {
+			if option.Name == "Hash" {
+				sb.WriteString(" memory=1")
+			}
+			if feature, ok := optionFeature(option); ok {
+				sb.WriteString(" option=\"" + feature + "\"")
+			}
+		}
+	}
+
+	s.send(sb.String())
+	s.send("feature done=1")
+}
+
+// optionFeature returns the CECP option feature of a UCI option.
+func optionFeature(option uci.Option) (string, bool) {
+	switch option.Type {
+	case "spin":
+		return fmt.Sprintf("%s -spin %s %d %d", option.Name, option.Default, option.Min, option.Max), true
+	case "check":
+		if option.Default == "true" {
+			return option.Name + " -check 1", true
+		}
+		return option.Name + " -check 0", true
+	case "button":
+		return option.Name + " -button", true
+	case "string":
+		return option.Name + " -string " + option.Default, true
+	case "combo":
+		vars := make([]string, len(option.Vars))
+		for i, v := range option.Vars {
+			if v == option.Default {
+				v = "*" + v
+			}
+			vars[i] = v
+		}
+		return option.Name + " -combo " + strings.Join(vars, " /// "), true
+	}
+	return "", false
+}
+
+// setOption handles an "option NAME=VALUE" or "option NAME" command.
+func (s *Server) setOption(argument string) error {
+	configurable, ok := s.searcher.(uci.Configurable)
+	if !ok {
+		return errors.New("The engine has no options.")
+	}
+
+	name, value, _ := strings.Cut(argument, "=")
+	for _, option := range configurable.Options() {
+		if option.Name != name {
+			continue
+		}
+
+		if option.Type == "check" {
+			value = strconv.FormatBool(value == "1")
+		}
+		return configurable.SetOption(name, value)
+	}
+
+	return fmt.Errorf("The option \"%s\" does not exist.", name)
+}
+
+// setBoard replaces the game with a new one, starting from the FEN.
+func (s *Server) setBoard(fen string) error {
+	newGame := chess.NewGame
+	if s.isChess960 {
+		newGame = chess.NewChess960GameFromFen
+	}
+
+	game, err := newGame(fen)
+	if err != nil {
+		return fmt.Errorf("The position is not valid: %w", err)
+	}
+
+	s.game = &game
+	return nil
+}
+
+// setLevel handles the fields of a "level MPS BASE INC" command, after
+// "level". BASE is in minutes, or "minutes:seconds", and INC in seconds.
+func (s *Server) setLevel(fields []string) error {
+	if len(fields) != 3 {
+		return errors.New("The level is not valid.")
+	}
+
+	movesPerSession, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return errors.New("The level is not valid.")
+	}
+
+	minutes, seconds, _ := strings.Cut(fields[1], ":")
+	baseMinutes, err := strconv.Atoi(minutes)
+	if err != nil {
+		return errors.New("The level is not valid.")
+	}
+	baseSeconds := 0
+	if seconds != "" {
+		if baseSeconds, err = strconv.Atoi(seconds); err != nil {
+			return errors.New("The level is not valid.")
+		}
+	}
+
+	increment, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return errors.New("The level is not valid.")
+	}
+
+	s.movesPerSession = movesPerSession
+	s.baseTime = time.Duration(baseMinutes)*time.Minute + time.Duration(baseSeconds)*time.Second
+	s.increment = time.Duration(increment * float64(time.Second))
+	s.engineTime, s.opponentTime = s.baseTime, s.baseTime
+	return nil
+}
+
+// userMove makes the opponent's movement, and starts thinking if it is
+// then the engine's turn.
+func (s *Server) userMove(algebraic string) error {
+	movement, err := s.parseMovement(algebraic)
+	if err != nil {
+		s.send("Illegal move: " + algebraic)
+		return nil
+	}
+
+	if err := s.game.MakeMovement(movement); err != nil {
+		s.send("Illegal move: " + algebraic)
+		return nil
+	}
+
+	if s.engineColor == chess.Color_None {
+		return nil
+	}
+
+	if outcome := s.game.Outcome(); outcome != chess.Outcome_None {
+		s.sendResult(outcome)
+		return nil
+	}
+
+	if s.game.CurrentPosition().Turn() == s.engineColor {
+		s.think()
+	}
+	return nil
+}
+
+// isCoordinateMovement reports whether the text has the format of a
+// movement in coordinate notation, such as "e2e4" or "e7e8q".
+func isCoordinateMovement(text string) bool {
+	if len(text) != 4 && len(text) != 5 {
+		return false
+	}
+	_, fromErr := chess.NewSquareFromAlgebraic(text[0:2])
+	_, toErr := chess.NewSquareFromAlgebraic(text[2:4])
+	return fromErr == nil && toErr == nil
+}
+
+// parseMovement returns the legal movement in coordinate notation or in
+// Standard Algebraic Notation, as sent by the GUI.
+func (s *Server) parseMovement(movement string) (chess.Movement, error) {
+	position := s.game.CurrentPosition()
+	if legalMovement, err := uci.ParseMovement(position, movement); err == nil {
+		return legalMovement, nil
+	}
+
+	// Castling is sent as "O-O" or "O-O-O" in Chess960 games, even in coordinate notation
+	return chess.ParseSAN(position, movement)
+}
+
+// think starts searching the engine's movement in the background, and
+// plays it once the search ends.
+func (s *Server) think() {
+	if s.game.Outcome() != chess.Outcome_None {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	search := &serverSearch{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.search = search
+
+	game := s.game
+	params := s.goParams()
+	notation := s.notation
+	post := s.post
+
+	go func() {
+		defer close(search.done)
+
+		onInfo := func(info uci.Info) {
+			if post && len(info.PV) > 0 {
+				s.send(thinkingOutput(game, info, notation))
+			}
+		}
+
+		result, err := s.searcher.Search(ctx, game, params, onInfo)
+		if search.discard.Load() {
+			return
+		}
+		if err == nil {
+			var movement chess.Movement
+			if movement, err = result.BestMovement(game); err == nil {
+				s.send("move " + formatMovement(game, movement, notation))
+				game.MakeMovement(movement)
+			}
+		}
+		if err != nil {
+			s.send("Error (" + strings.TrimSuffix(err.Error(), ".") + "): go")
+			return
+		}
+
+		if outcome := game.Outcome(); outcome != chess.Outcome_None {
+			s.sendResult(outcome)
+		}
+	}()
+}
+
+// goParams returns the search parameters of the current time controls.
+func (s *Server) goParams() uci.GoParams {
+	params := uci.GoParams{
+		Depth:    s.depth,
+		MoveTime: s.moveTime,
+	}
+
+	if s.moveTime == 0 && s.engineTime > 0 {
+		params.WTime, params.BTime = s.engineTime, s.opponentTime
+		if s.engineColor == chess.Color_Black {
+			params.WTime, params.BTime = s.opponentTime, s.engineTime
+		}
+		params.WInc, params.BInc = s.increment, s.increment
+
+		if s.movesPerSession > 0 {
+			played := int(s.game.CurrentPosition().FullmoveCounter()) - 1
+			params.MovesToGo = s.movesPerSession - played%s.movesPerSession
+		}
+	}
+
+	if params.Depth == 0 && params.MoveTime == 0 && params.WTime == 0 && params.BTime == 0 {
+		params.MoveTime = defaultMoveTime
+	}
+
+	return params
+}
+
+// stopSearch stops the running search, if any, and waits until it ends.
+// The searched movement is played, unless discard is set.
+func (s *Server) stopSearch(discard bool) {
+	if s.search == nil {
+		return
+	}
+
+	s.search.discard.Store(discard)
+	s.search.cancel()
+	<-s.search.done
+	s.search = nil
+}
+
+func (s *Server) sendResult(outcome chess.Outcome) {
+	s.send(outcome.PGNResult() + " {" + resultComment(outcome) + "}")
+}
+
+func (s *Server) send(line string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	io.WriteString(s.w, line+"\n")
+}
+
+// formatMovement returns the movement of the game's current position in
+// the passed notation.
+func formatMovement(game *chess.Game, movement chess.Movement, notation Notation) string {
+	if notation == Notation_SAN {
+		san, _ := game.MovementSAN(movement)
+		return san
+	}
+
+	// XBoard expects Chess960 castling as "O-O" or "O-O-O"
+	if game.CurrentPosition().IsChess960() {
+		if movement.IsKingSideCastling() {
+			return "O-O"
+		} else if movement.IsQueenSideCastling() {
+			return "O-O-O"
+		}
+	}
+	return movement.Algebraic()
+}
+
+// thinkingOutput returns the info as a CECP thinking output line: depth,
+// score, time in centiseconds, nodes and principal variation.
+func thinkingOutput(game *chess.Game, info uci.Info, notation Notation) string {
+	// Mate scores are sent as 100000 + the movements to mate
+	score := info.Score.Centipawns
+	if info.Score.IsMate && info.Score.Mate >= 0 {
+		score = 100000 + info.Score.Mate
+	} else if info.Score.IsMate {
+		score = -100000 + info.Score.Mate
+	}
+
+	newGame := chess.NewGame
+	if game.CurrentPosition().IsChess960() {
+		newGame = chess.NewChess960GameFromFen
+	}
+	replay, _ := newGame(game.CurrentFen())
+
+	pv := make([]string, 0, len(info.PV))
+	for _, algebraic := range info.PV {
+		movement, err := uci.ParseMovement(replay.CurrentPosition(), algebraic)
+		if err != nil {
+			break
+		}
+		pv = append(pv, formatMovement(&replay, movement, notation))
+		replay.MakeMovement(movement)
+	}
+
+	return fmt.Sprintf("%d %d %d %d %s", info.Depth, score, info.Time.Milliseconds()/10, info.Nodes, strings.Join(pv, " "))
+}
+
+// resultComment returns the comment of a CECP result line of the outcome.
+//
+// Examples:
+//
+//	resultComment(Outcome_Checkmate_White) // returns "White mates"
+//	resultComment(Outcome_Draw_Stalemate)  // returns "Stalemate"
+func resultComment(outcome chess.Outcome) string {
+	switch outcome.Method() {
+	case chess.Method_Checkmate:
+		if outcome.Winner() == chess.Color_White {
+			return "White mates"
+		}
+		return "Black mates"
+	case chess.Method_Stalemate:
+		return "Stalemate"
+	case chess.Method_Repetition:
+		return "Draw by repetition"
+	case chess.Method_MoveRule:
+		return "Draw by fifty move rule"
+	case chess.Method_InsufficientMaterial:
+		return "Insufficient material"
+	}
+	return string(outcome)
+}
+
+// resultOutcome returns the Outcome of a "result RESULT {COMMENT}" command's
+// arguments, using the comment to find out how the game ended.
+//
+// If the game was aborted ("*") or the result is invalid, it will return
+// Outcome_None.
+func resultOutcome(arguments string) chess.Outcome {
+	result, comment, _ := strings.Cut(arguments, " ")
+	comment = strings.ToLower(comment)
+
+	var winner chess.Color
+	switch result {
+	case "1-0":
+		winner = chess.Color_White
+	case "0-1":
+		winner = chess.Color_Black
+	case "1/2-1/2":
+		winner = chess.Color_None
+	default:
+		return chess.Outcome_None
+	}
+
+	method := chess.Method_Adjudication
+	switch {
+	case strings.Contains(comment, "resign"):
+		method = chess.Method_Resignation
+	case strings.Contains(comment, "time") || strings.Contains(comment, "flag"):
+		if winner == chess.Color_None {
+			return chess.Outcome_Draw_Timeout
+		}
+		method = chess.Method_Timeout
+	case strings.Contains(comment, "stalemate"):
+		method = chess.Method_Stalemate
+	case strings.Contains(comment, "mate"):
+		method = chess.Method_Checkmate
+	case strings.Contains(comment, "repetition"):
+		method = chess.Method_Repetition
+	case strings.Contains(comment, "50") || strings.Contains(comment, "fifty"):
+		method = chess.Method_MoveRule
+	case strings.Contains(comment, "insufficient"):
+		method = chess.Method_InsufficientMaterial
+	case strings.Contains(comment, "agree"):
+		method = chess.Method_Agreement
+	}
+
+	if outcome := chess.NewOutcome(method, winner); outcome != chess.Outcome_None {
+		return outcome
+	}
+	return chess.NewOutcome(chess.Method_Adjudication, winner)
+}
@@ -0,0 +1,235 @@
+package cecp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/uci"
+)
+
+// fakeSearcher plays the first legal movement, or the one set, recording
+// its searches.
+type fakeSearcher struct {
+	params   []uci.GoParams
+	movement string
+	wait     bool // Whether to search until stopped
+	options  map[string]string
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, game *chess.Game, params uci.GoParams, onInfo func(uci.Info)) (uci.SearchResult, error) {
+	f.params = append(f.params, params)
+
+	best := f.movement
+	if best == "" {
+		best = game.LegalMovements()[0].Algebraic()
+	}
+	onInfo(uci.Info{Depth: 3, Score: uci.Score{Mate: 2, IsMate: true}, HasScore: true, Nodes: 1500, Time: 120 * time.Millisecond, PV: []string{best}})
+
+	if f.wait {
+		<-ctx.Done()
+	}
+	return uci.SearchResult{BestMove: best}, nil
+}
+
+func (f *fakeSearcher) Options() []uci.Option {
+	return []uci.Option{{Name: "Hash", Type: "spin", Default: "16", Min: 1, Max: 1024}, {Name: "Ponder", Type: "check", Default: "false"}}
+}
+
+func (f *fakeSearcher) SetOption(name, value string) error {
+	f.options[name] = value
+	return nil
+}
+
+// fakeGUI sends commands to a Server, and reads its answers.
+type fakeGUI struct {
+	t     *testing.T
+	w     io.Writer
+	lines chan string
+}
+
+func newFakeGUI(t *testing.T, server *Server) (*fakeGUI, chan error) {
+	guiReader, serverWriter := io.Pipe()
+	serverReader, guiWriter := io.Pipe()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(serverReader, serverWriter)
+		serverWriter.Close()
+	}()
+
+	// Lines are always read, as a GUI would, so the server never blocks
+	gui := &fakeGUI{t: t, w: guiWriter, lines: make(chan string, 256)}
+	go func() {
+		scanner := bufio.NewScanner(guiReader)
+		for scanner.Scan() {
+			gui.lines <- scanner.Text()
+		}
+		close(gui.lines)
+	}()
+
+	return gui, served
+}
+
+func (g *fakeGUI) send(commands ...string) {
+	for _, command := range commands {
+		io.WriteString(g.w, command+"\n")
+	}
+}
+
+// readUntil reads lines until the passed one, and returns all of them.
+func (g *fakeGUI) readUntil(last string) []string {
+	lines := make([]string, 0)
+	for {
+		line := g.expect("")
+		lines = append(lines, line)
+		if line == last {
+			return lines
+		}
+	}
+}
+
+// expect reads lines until one starts with prefix, and returns it.
+func (g *fakeGUI) expect(prefix string) string {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-g.lines:
+			if !ok {
+				g.t.Fatalf("Expected a line starting with %q, but the server ended", prefix)
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-timeout:
+			g.t.Fatalf("Expected a line starting with %q", prefix)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	searcher := &fakeSearcher{movement: "e7e5", options: make(map[string]string)}
+	server := NewServer("Fake Engine", searcher)
+	gui, served := newFakeGUI(t, server)
+
+	gui.send("xboard", "protover 2")
+	if features := gui.expect("feature"); !strings.Contains(features, `myname="Fake Engine"`) || !strings.Contains(features, `option="Hash -spin 16 1 1024"`) || !strings.Contains(features, "memory=1") {
+		t.Errorf("Unexpected features %q", features)
+	}
+	gui.expect("feature done=1")
+
+	gui.send("new", "memory 64", "option Ponder=1", "level 40 5 2", "post", "time 30000", "otim 29000", "usermove e2e4")
+	if movement := gui.expect("move"); movement != "move e7e5" {
+		t.Errorf("Expected the engine to answer with e7e5, got %q", movement)
+	}
+	if searcher.options["Hash"] != "64" || searcher.options["Ponder"] != "true" {
+		t.Errorf("Expected the options to be set, got %v", searcher.options)
+	}
+
+	params := searcher.params[0]
+	if params.BTime != 300*time.Second || params.WTime != 290*time.Second || params.BInc != 2*time.Second || params.MovesToGo != 40 {
+		t.Errorf("Unexpected search params %+v", params)
+	}
+
+	searcher.movement = "b8c6"
+	gui.send("st 2", "sd 6", "usermove g1f3")
+	gui.expect("move b8c6")
+	if params := searcher.params[1]; params.MoveTime != 2*time.Second || params.Depth != 6 || params.WTime != 0 {
+		t.Errorf("Unexpected search params %+v", params)
+	}
+
+	gui.send("force", "usermove f1c4", "remove", "undo", "ping 2")
+	gui.expect("pong 2")
+	if server.game.CurrentFen() != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("Expected the movements to be taken back, got %q", server.game.CurrentFen())
+	}
+
+	gui.send("usermove e2e5")
+	if line := gui.expect("Illegal move"); line != "Illegal move: e2e5" {
+		t.Errorf("Unexpected line %q", line)
+	}
+	gui.send("foo")
+	gui.expect("Error (unknown command): foo")
+
+	// Checkmating movements end the game, in Standard Algebraic Notation
+	server.SetNotation(Notation_SAN)
+	searcher.movement = "d8h4"
+	gui.send("setboard rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "go")
+	// Thinking output is sent before the movement, with mate scores as 100000 + movements
+	if line := gui.expect("3 "); line != "3 100002 12 1500 Qh4#" {
+		t.Errorf("Unexpected thinking output %q", line)
+	}
+	gui.expect("move Qh4#")
+	if result := gui.expect("0-1"); result != "0-1 {Black mates}" || server.game.Outcome() != chess.Outcome_Checkmate_Black {
+		t.Errorf("Expected the game to end by checkmate, got %q", result)
+	}
+
+	// "?" plays the movement found so far, and other commands discard it
+	searcher.movement, searcher.wait = "d2d4", true
+	gui.send("new", "go", "?")
+	gui.expect("move d4")
+	gui.send("new", "go", "force", "result 1-0 {Black resigns}", "ping 3")
+	gui.expect("pong 3")
+	if server.game.Outcome() != chess.Outcome_Resignation_White || len(server.game.MovementHistory()) != 0 {
+		t.Errorf("Expected the game to end by resignation without movements, got %s", server.game.Outcome())
+	}
+
+	gui.send("quit")
+	if err := <-served; err != nil {
+		t.Errorf("Expected the server to end on quit, got %s", err)
+	}
+}
+
+func TestServerMovementWithoutUsermove(t *testing.T) {
+	searcher := &fakeSearcher{options: make(map[string]string), wait: true}
+	server := NewServer("Fake Engine", searcher)
+	gui, served := newFakeGUI(t, server)
+
+	// The search is stopped before the movement is parsed, so the engine
+	// doesn't play and the movement is illegal for white
+	gui.send("new", "go", "e7e5", "ping 1")
+	if lines := gui.readUntil("pong 1"); strings.Join(lines, "|") != "Illegal move: e7e5|pong 1" {
+		t.Errorf("Unexpected output %q", lines)
+	}
+	if len(server.game.MovementHistory()) != 0 {
+		t.Errorf("Expected no movements, got %v", server.game.MovementHistorySAN())
+	}
+
+	gui.send("force", "e2e4", "e7e5", "ping 2")
+	if lines := gui.readUntil("pong 2"); strings.Join(lines, "|") != "pong 2" {
+		t.Errorf("Unexpected output %q", lines)
+	}
+	if history := strings.Join(server.game.MovementHistorySAN(), " "); history != "e4 e5" {
+		t.Errorf("Unexpected movements %s", history)
+	}
+
+	gui.send("quit")
+	if err := <-served; err != nil {
+		t.Errorf("Expected the server to end on quit, got %s", err)
+	}
+}
+
+func TestResultOutcome(t *testing.T) {
+	tests := map[string]chess.Outcome{
+		"1-0 {White mates}":            chess.Outcome_Checkmate_White,
+		"0-1 {White resigns}":          chess.Outcome_Resignation_Black,
+		"1/2-1/2 {Stalemate}":          chess.Outcome_Draw_Stalemate,
+		"1/2-1/2 {Draw by repetition}": chess.Outcome_Draw_3Rep,
+		"1/2-1/2 {Draw by agreement}":  chess.Outcome_Draw_Agreement,
+		"0-1 {White forfeits on time}": chess.Outcome_Timeout_Black,
+		"1-0 {Xboard adjudication}":    chess.Outcome_Adjudication_White,
+		"1/2-1/2 {Stalemate by time}":  chess.Outcome_Draw_Timeout,
+		"1-0 {Stalemate}":              chess.Outcome_Adjudication_White,
+		"* {Game aborted}":             chess.Outcome_None,
+	}
+
+	for arguments, expected := range tests {
+		if outcome := resultOutcome(arguments); outcome != expected {
+			t.Errorf("Expected %q to be %s, got %s", arguments, expected, outcome)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Placement of the two knights among the 5 empty squares left after placing
+// the bishops and queen, for each Scharnagl knight code.
+var chess960KnightPlacements = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// NewChess960Game creates and returns a new Game instance of Chess960 (Fischer
+// Random Chess), with the starting position of the passed Scharnagl number.
+//
+// If the number is not in range [0, 960), it will return an empty Game and the error.
+//
+// Example:
+//
+//	NewChess960Game(518) // the standard starting position, with Chess960 castling
+func NewChess960Game(number int) (Game, error) {
+	fen, err := Chess960Fen(number)
+	if err != nil {
+		return Game{}, err
+	}
+
+	return NewChess960GameFromFen(fen)
+}
+
+// NewChess960GameFromFen creates and returns a new Chess960 Game instance,
+// based on the provided FEN string. The castling rights can be in
+// standard, X-FEN or Shredder-FEN notation.
+//
+// Unlike NewGame(), castling movements are always written as the king taking
+// its own rook, even if the position has the standard king and rook squares.
+//
+// If the provided FEN is invalid, it will return an empty Game, along with it's error.
+func NewChess960GameFromFen(fen string) (Game, error) {
+	startingPosition, err := newPositionFromFen(fen)
+	if err != nil {
+		return Game{}, err
+	}
+	startingPosition.isChess960 = true
+
+	return newGameFromPosition(startingPosition), nil
+}
+
+// Chess960Fen returns the FEN of the Chess960 starting position of the
+// passed Scharnagl number, with the castling rights in X-FEN.
+//
+// If the number is not in range [0, 960), it will return an empty string and the error.
+//
+// Examples:
+//
+//	Chess960Fen(518) // returns "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil
+//	Chess960Fen(0)   // returns "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1", nil
+func Chess960Fen(number int) (string, error) {
+	if number < 0 || number >= 960 {
+		return "", errors.New("The Chess960 starting position number must be in range [0, 960).")
+	}
+
+	var rank [8]Kind
+
+	// Bishops on light and dark squares
+	rank[number%4*2+1] = Kind_Bishop
+	number /= 4
+	rank[number%4*2] = Kind_Bishop
+	number /= 4
+
+	placeOnEmpty := func(emptyIndex int, kind Kind) {
+		for file := range rank {
+			if rank[file] != Kind_None {
+				continue
+			}
+			if emptyIndex == 0 {
+				rank[file] = kind
+				return
+			}
+			emptyIndex--
+		}
+	}
+
+	placeOnEmpty(number%6, Kind_Queen)
+	number /= 6
+
+	// The second knight is placed after the first one, so its index is one less
+	knights := chess960KnightPlacements[number]
+	placeOnEmpty(knights[0], Kind_Knight)
+	placeOnEmpty(knights[1]-1, Kind_Knight)
+
+	// The king is always between the rooks
+	placeOnEmpty(0, Kind_Rook)
+	placeOnEmpty(0, Kind_King)
+	placeOnEmpty(0, Kind_Rook)
+
+	var black, white strings.Builder
+	for _, kind := range rank {
+		black.WriteRune(kind.Rune())
+		white.WriteRune(unicode.ToUpper(kind.Rune()))
+	}
+
+	return black.String() + "/pppppppp/8/8/8/8/PPPPPPPP/" + white.String() + " w KQkq - 0 1", nil
+}
+
+// Chess960Number returns the Scharnagl number of the position's back ranks,
+// which is in range [0, 960).
+//
+// If the pieces of the back ranks are not a Chess960 starting position
+// (mirrored for both players), it will return -1 and the error.
+//
+// Example:
+//
+//	NewGame("") // CurrentPosition().Chess960Number() returns 518, nil
+func (p Position) Chess960Number() (int, error) {
+	invalidErr := errors.New("The position's back ranks are not a Chess960 starting position.")
+
+	var rank [8]Kind
+	for file := range rank {
+		white, black := p.board[7][file], p.board[0][file]
+		if white.Color != Color_White || black.Color != Color_Black || white.Kind != black.Kind {
+			return -1, invalidErr
+		}
+		rank[file] = white.Kind
+	}
+
+	lightBishop, darkBishop, queen, knights := -1, -1, -1, make([]int, 0, 2)
+	rooks, kingBetweenRooks := 0, false
+	emptyIndex := 0 // Index among the squares not taken by the bishops
+
+	for file, kind := range rank {
+		if kind != Kind_Bishop {
+			if kind == Kind_Queen {
+				queen = emptyIndex
+			} else if kind == Kind_Knight {
+				// Knights are indexed among the squares not taken by the queen either
+				knights = append(knights, emptyIndex)
+			}
+			emptyIndex++
+		}
+
+		switch kind {
+		case Kind_Bishop:
+			if file%2 == 1 && lightBishop == -1 {
+				lightBishop = file / 2
+			} else if file%2 == 0 && darkBishop == -1 {
+				darkBishop = file / 2
+			} else {
+				return -1, invalidErr
+			}
+		case Kind_Rook:
+			rooks++
+		case Kind_King:
+			kingBetweenRooks = rooks == 1
+		}
+	}
+
+	if lightBishop == -1 || darkBishop == -1 || queen == -1 || len(knights) != 2 || rooks != 2 || !kingBetweenRooks {
+		return -1, invalidErr
+	}
+
+	for i := range knights {
+		if knights[i] > queen {
+			knights[i]--
+		}
+	}
+
+	knightCode := -1
+	for code, placement := range chess960KnightPlacements {
+		if placement[0] == knights[0] && placement[1] == knights[1] {
+			knightCode = code
+		}
+	}
+
+	return lightBishop + 4*darkBishop + 16*queen + 96*knightCode, nil
+}
+
+// IsChess960 reports whether the position is from a Chess960 game, in which
+// castling movements are written as the king taking its own rook.
+//
+// Positions read from a FEN are Chess960 if any castling king or rook is not
+// on its standard square.
+func (p Position) IsChess960() bool {
+	return p.isChess960
+}
+
+// castlingKingFile returns the file of the passed color's king, if it is on
+// its castling row. Otherwise, it returns the standard e file.
+func (p *Position) castlingKingFile(color Color) uint8 {
+	row := castlingRows[color]
+	for file := uint8(0); file < 8; file++ {
+		if p.pieceBitboards[color][Kind_King].has(int(row)*8 + int(file)) {
+			return file
+		}
+	}
+	return 4
+}
+
+// outermostRookFile returns the file of the passed color's rook that is the
+// furthest from the king on the passed side, in the castling row.
+//
+// If there is no rook, it returns the standard a or h file.
+func (p *Position) outermostRookFile(color Color, isQueenSide bool) uint8 {
+	row, kingFile := castlingRows[color], p.castlingKingFile(color)
+
+	if isQueenSide {
+		for file := uint8(0); file < kingFile; file++ {
+			if p.pieceBitboards[color][Kind_Rook].has(int(row)*8 + int(file)) {
+				return file
+			}
+		}
+		return 0
+	}
+
+	for file := uint8(7); file > kingFile; file-- {
+		if p.pieceBitboards[color][Kind_Rook].has(int(row)*8 + int(file)) {
+			return file
+		}
+	}
+	return 7
+}
+
+// setCastlingFen sets the castling rights from the FEN castling field, in
+// standard, X-FEN or Shredder-FEN notation. It assumes that the field is valid,
+// and that the pieces are already placed.
+func (p *Position) setCastlingFen(field string) {
+	p.castlingRights = CastlingRights{
+		queenSideRookFile: [COLOR_AMOUNT + 1]uint8{Color_White: 0, Color_Black: 0},
+		kingSideRookFile:  [COLOR_AMOUNT + 1]uint8{Color_White: 7, Color_Black: 7},
+	}
+
+	if field == "-" {
+		return
+	}
+
+	for _, r := range field {
+		color := Color_White
+		if unicode.IsLower(r) {
+			color = Color_Black
+		}
+
+		switch unicode.ToUpper(r) {
+		case 'K':
+			p.castlingRights.kingSide[color] = true
+			p.castlingRights.kingSideRookFile[color] = p.outermostRookFile(color, false)
+		case 'Q':
+			p.castlingRights.queenSide[color] = true
+			p.castlingRights.queenSideRookFile[color] = p.outermostRookFile(color, true)
+		default:
+			// Rook file, its side depends on the king's file
+			file := uint8(unicode.ToUpper(r) - 'A')
+			if file > p.castlingKingFile(color) {
+				p.castlingRights.kingSide[color] = true
+				p.castlingRights.kingSideRookFile[color] = file
+			} else {
+				p.castlingRights.queenSide[color] = true
+				p.castlingRights.queenSideRookFile[color] = file
+			}
+		}
+	}
+
+	// Castling from non standard squares is only possible in Chess960
+	for _, color := range [2]Color{Color_White, Color_Black} {
+		hasCastlingRights := p.castlingRights.queenSide[color] || p.castlingRights.kingSide[color]
+		if hasCastlingRights && (p.castlingKingFile(color) != 4 ||
+			(p.castlingRights.queenSide[color] && p.castlingRights.queenSideRookFile[color] != 0) ||
+			(p.castlingRights.kingSide[color] && p.castlingRights.kingSideRookFile[color] != 7)) {
+			p.isChess960 = true
+		}
+	}
+}
+
+// castlingFen returns the FEN castling field of the position, in X-FEN, or
+// in Shredder-FEN if shredder is set.
+func (p *Position) castlingFen(shredder bool) string {
+	var sb strings.Builder
+
+	for _, color := range [2]Color{Color_White, Color_Black} {
+		sides := [2]struct {
+			hasRight bool
+			rookFile uint8
+			isQueen  bool
+			letter   rune
+		}{
+			{p.castlingRights.kingSide[color], p.castlingRights.kingSideRookFile[color], false, 'K'},
+			{p.castlingRights.queenSide[color], p.castlingRights.queenSideRookFile[color], true, 'Q'},
+		}
+
+		for _, side := range sides {
+			if !side.hasRight {
+				continue
+			}
+
+			letter := side.letter
+			if shredder || side.rookFile != p.outermostRookFile(color, side.isQueen) {
+				letter = 'A' + rune(side.rookFile)
+			}
+
+			if color == Color_Black {
+				letter = unicode.ToLower(letter)
+			}
+			sb.WriteRune(letter)
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
@@ -0,0 +1,152 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChess960Numbers(t *testing.T) {
+	fens := map[int]string{
+		0:   "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1",
+		518: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		959: "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1",
+	}
+	for number, expectedFen := range fens {
+		if fen, _ := Chess960Fen(number); fen != expectedFen {
+			t.Errorf("Expected position %d to be %q, got %q", number, expectedFen, fen)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for number := 0; number < 960; number++ {
+		game, err := NewChess960Game(number)
+		if err != nil {
+			t.Fatalf("Unexpected error creating position %d: %s", number, err)
+		}
+
+		if parsedNumber, err := game.CurrentPosition().Chess960Number(); err != nil || parsedNumber != number {
+			t.Fatalf("Expected %q to be position %d, got %d (%v)", game.CurrentFen(), number, parsedNumber, err)
+		}
+		seen[game.CurrentFen()] = true
+	}
+	if len(seen) != 960 {
+		t.Errorf("Expected 960 different positions, got %d", len(seen))
+	}
+
+	if _, err := Chess960Fen(960); err == nil {
+		t.Errorf("Expected an error for position 960")
+	}
+	game, _ := NewGame("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKQBNR w - - 0 1")
+	if _, err := game.CurrentPosition().Chess960Number(); err == nil {
+		t.Errorf("Expected an error for non mirrored back ranks")
+	}
+}
+
+func TestChess960Fen(t *testing.T) {
+	tests := []struct {
+		fen, xFen, shredderFen string
+		isChess960             bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", false},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", false},
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", true},
+		// The inner rook is written with its file in X-FEN
+		{"rr2k3/8/8/8/8/8/8/RR2K3 w Bb - 0 1", "rr2k3/8/8/8/8/8/8/RR2K3 w Bb - 0 1", "rr2k3/8/8/8/8/8/8/RR2K3 w Bb - 0 1", true},
+		{"rr2k3/8/8/8/8/8/8/RR2K3 w Qq - 0 1", "rr2k3/8/8/8/8/8/8/RR2K3 w Qq - 0 1", "rr2k3/8/8/8/8/8/8/RR2K3 w Aa - 0 1", false},
+	}
+
+	for _, test := range tests {
+		game, err := NewGame(test.fen)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", test.fen, err)
+		}
+
+		position := game.CurrentPosition()
+		if position.Fen() != test.xFen || position.ShredderFen() != test.shredderFen {
+			t.Errorf("Expected %q to be written as %q and %q, got %q and %q", test.fen, test.xFen, test.shredderFen, position.Fen(), position.ShredderFen())
+		}
+		if position.IsChess960() != test.isChess960 {
+			t.Errorf("Expected %q IsChess960() to be %t", test.fen, test.isChess960)
+		}
+	}
+
+	if IsFenValid("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQxq - 0 1") {
+		t.Errorf("Expected invalid castling rights to be rejected")
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	// King on b1 and rook on a1: queenside castling moves the king to c1 and the rook to d1
+	game, _ := NewChess960GameFromFen("r3k2r/8/8/8/8/8/8/RK5R w AHah - 0 1")
+
+	if !game.IsMovementLegalAlgebraic("b1a1") || !game.IsMovementLegalAlgebraic("b1h1") || game.IsMovementLegalAlgebraic("b1g1") {
+		t.Fatalf("Expected castling to be written as king takes rook, got %v", game.LegalMovementsAlgebraic())
+	}
+
+	game.MakeMovementAlgebraic("b1a1")
+	if game.CurrentFen() != "r3k2r/8/8/8/8/8/8/2KR3R b kq - 1 1" {
+		t.Errorf("Expected queenside castling, got %q", game.CurrentFen())
+	}
+	if san := game.MovementHistorySAN(); len(san) != 1 || san[0] != "O-O-O" {
+		t.Errorf("Expected O-O-O, got %v", san)
+	}
+
+	if err := game.MakeMovementSAN("O-O"); err != nil {
+		t.Fatalf("Unexpected error castling kingside: %s", err)
+	}
+	if game.CurrentFen() != "r4rk1/8/8/8/8/8/8/2KR3R w - - 2 2" {
+		t.Errorf("Expected kingside castling, got %q", game.CurrentFen())
+	}
+	game.UndoMovement()
+
+	// A castling rook can not hide an attack to the king's final square
+	hidden, _ := NewChess960GameFromFen("4k3/8/8/8/8/8/8/qRK5 w B - 0 1")
+	if hidden.IsMovementLegalAlgebraic("c1b1") {
+		t.Errorf("Expected castling into the queen's attack to be illegal")
+	}
+
+	// The king can stay on its square, with only the rook moving
+	staying, _ := NewChess960GameFromFen("4k3/8/8/8/8/8/8/6KR w H - 0 1")
+	if err := staying.MakeMovementAlgebraic("g1h1"); err != nil || staying.CurrentFen() != "4k3/8/8/8/8/8/8/5RK1 b - - 1 1" {
+		t.Errorf("Expected castling with the king staying on g1, got %q (%v)", staying.CurrentFen(), err)
+	}
+}
+
+func TestChess960Perft(t *testing.T) {
+	tests := []struct {
+		fen   string
+		nodes []uint64
+	}{
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []uint64{21, 528, 12189, 326672}},
+		{"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []uint64{21, 807, 18002, 667366}},
+		{"b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []uint64{20, 479, 10471, 273318}},
+	}
+
+	for _, test := range tests {
+		game, _ := NewGame(test.fen)
+		for depth, nodes := range test.nodes {
+			if result := game.Perft(depth + 1); result != nodes {
+				t.Errorf("Expected perft %d of %q to be %d, got %d", depth+1, test.fen, nodes, result)
+			}
+		}
+	}
+}
+
+func TestChess960PGN(t *testing.T) {
+	game, _ := NewChess960GameFromFen("r3k2r/8/8/8/8/8/8/RK5R w AHah - 0 1")
+	game.MakeMovementAlgebraic("b1a1")
+	game.MakeMovementSAN("O-O")
+
+	pgn := game.PGN()
+	parsed, err := ParsePGN(strings.NewReader(pgn))
+	if err != nil || len(parsed) != 1 {
+		t.Fatalf("Unexpected error parsing %q: %v", pgn, err)
+	}
+
+	if !strings.Contains(pgn, `[Variant "Chess960"]`) || !parsed[0].Game.CurrentPosition().IsChess960() {
+		t.Errorf("Expected the Chess960 variant to be kept in %q", pgn)
+	}
+	if parsed[0].Game.CurrentFen() != game.CurrentFen() {
+		t.Errorf("Expected %q, got %q", game.CurrentFen(), parsed[0].Game.CurrentFen())
+	}
+}
@@ -0,0 +1,369 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IncrementMode represents how a TimeControl's increment is applied
+// to a player's clock.
+type IncrementMode uint8
+
+const (
+	IncrementMode_Fischer   IncrementMode = iota // The increment is added after each movement
+	IncrementMode_Delay                          // The clock does not run during the increment (simple/US delay)
+	IncrementMode_Bronstein                      // The time used, up to the increment, is added back after each movement
+)
+
+// TimeControlStage represents a stage of a TimeControl. For example, the
+// first 40 movements in 90 minutes, with an increment of 30 seconds.
+type TimeControlStage struct {
+	// Movements is the amount of movements each player must make in this stage.
+	// If it is 0, the stage lasts until the end of the game.
+	Movements int
+
+	// Time is the time added to each player's clock when the stage starts.
+	Time time.Duration
+
+	// Increment is the increment or delay of each movement of the stage.
+	Increment time.Duration
+}
+
+// TimeControl represents the time control of a timed game, that consists
+// of one or more stages. Once all the stages are completed, the last one is
+// repeated.
+//
+// Examples:
+//
+//	// Sudden death: 5 minutes
+//	TimeControl{Stages: []TimeControlStage{{Time: 5 * time.Minute}}}
+//
+//	// Fischer increment: 3 minutes + 2 seconds
+//	TimeControl{Stages: []TimeControlStage{{Time: 3 * time.Minute, Increment: 2 * time.Second}}}
+//
+//	// Multi-stage: 40 movements in 90 minutes, then 30 minutes, with 30 seconds increment
+//	TimeControl{Stages: []TimeControlStage{
+//		{Movements: 40, Time: 90 * time.Minute, Increment: 30 * time.Second},
+//		{Time: 30 * time.Minute, Increment: 30 * time.Second},
+//	}}
+type TimeControl struct {
+	Stages []TimeControlStage
+	Mode   IncrementMode
+}
+
+// ParseTimeControl returns the TimeControl of the passed string, in the PGN
+// "TimeControl" tag format. That is, one or more stages separated by ":", with
+// the format "[movements/]seconds[+increment]".
+//
+// The increment mode of the returned TimeControl is IncrementMode_Fischer.
+//
+// Examples:
+//
+//	ParseTimeControl("300")                // 5 minutes, sudden death
+//	ParseTimeControl("180+2")              // 3 minutes, 2 seconds increment
+//	ParseTimeControl("40/5400+30:1800+30") // 40 movements in 90 minutes, then 30 minutes, 30 seconds increment
+func ParseTimeControl(timeControl string) (TimeControl, error) {
+	fields := strings.Split(strings.TrimSpace(timeControl), ":")
+	stages := make([]TimeControlStage, 0, len(fields))
+
+	for i, field := range fields {
+		var stage TimeControlStage
+
+		if movements, rest, found := strings.Cut(field, "/"); found {
+			parsedMovements, err := strconv.Atoi(movements)
+			if err != nil || parsedMovements < 1 {
+				return TimeControl{}, fmt.Errorf("The time control stage \"%s\" does not have a valid amount of movements.", field)
+			}
+			stage.Movements = parsedMovements
+			field = rest
+		} else if i != len(fields)-1 {
+			return TimeControl{}, errors.New("Only the last time control stage can last until the end of the game.")
+		}
+
+		seconds, increment, hasIncrement := strings.Cut(field, "+")
+		parsedSeconds, err := strconv.ParseFloat(seconds, 64)
+		if err != nil || parsedSeconds <= 0 {
+			return TimeControl{}, fmt.Errorf("The time control stage \"%s\" does not have a valid time.", field)
+		}
+		stage.Time = time.Duration(parsedSeconds * float64(time.Second))
+
+		if hasIncrement {
+			parsedIncrement, err := strconv.ParseFloat(increment, 64)
+			if err != nil || parsedIncrement < 0 {
+				return TimeControl{}, fmt.Errorf("The time control stage \"%s\" does not have a valid increment.", field)
+			}
+			stage.Increment = time.Duration(parsedIncrement * float64(time.Second))
+		}
+
+		stages = append(stages, stage)
+	}
+
+	return TimeControl{
+		Stages: stages,
+		Mode:   IncrementMode_Fischer,
+	}, nil
+}
+
+// String returns the time control in the PGN "TimeControl" tag format.
+//
+// Examples:
+//
+//	"300"
+//	"180+2"
+//	"40/5400+30:1800+30"
+func (tc TimeControl) String() string {
+	fields := make([]string, len(tc.Stages))
+	for i, stage := range tc.Stages {
+		var sb strings.Builder
+		if stage.Movements > 0 {
+			sb.WriteString(strconv.Itoa(stage.Movements) + "/")
+		}
+		sb.WriteString(strconv.FormatFloat(stage.Time.Seconds(), 'f', -1, 64))
+		if stage.Increment > 0 {
+			sb.WriteString("+" + strconv.FormatFloat(stage.Increment.Seconds(), 'f', -1, 64))
+		}
+		fields[i] = sb.String()
+	}
+	return strings.Join(fields, ":")
+}
+
+// MovementTime represents the clock data of a movement made in a timed game.
+type MovementTime struct {
+	Timestamp time.Time     // When the movement was made
+	Elapsed   time.Duration // Time spent by the player on the movement
+	Remaining time.Duration // Time left on the player's clock after the movement, including increments
+}
+
+// Clock represents a chess clock, that keeps the remaining time of both
+// players according to a TimeControl.
+//
+// Note: If you intend in creating a new Clock, use NewClock() function.
+type Clock struct {
+	timeControl TimeControl
+	now         func() time.Time
+
+	remaining      [COLOR_AMOUNT + 1]time.Duration
+	stage          [COLOR_AMOUNT + 1]int
+	stageMovements [COLOR_AMOUNT + 1]int
+	running        Color // Color_None if the clock is stopped
+	turnStartedAt  time.Time
+	flaggedColor   Color // Color_None if no player ran out of time
+}
+
+// NewClock creates and returns a new stopped Clock, with both players'
+// remaining time set to the first stage of the passed time control.
+//
+// The now function is used as the time source, which can be replaced
+// for testing. If it is nil, time.Now will be used.
+//
+// If the time control has no stages, it will return nil and an error.
+func NewClock(timeControl TimeControl, now func() time.Time) (*Clock, error) {
+	if len(timeControl.Stages) == 0 {
+		return nil, errors.New("The time control must have at least one stage.")
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	clock := &Clock{
+		timeControl:  timeControl,
+		now:          now,
+		running:      Color_None,
+		flaggedColor: Color_None,
+	}
+
+	clock.remaining[Color_White] = timeControl.Stages[0].Time
+	clock.remaining[Color_Black] = timeControl.Stages[0].Time
+
+	return clock, nil
+}
+
+// TimeControl returns the clock's time control.
+func (c *Clock) TimeControl() TimeControl {
+	return c.timeControl
+}
+
+// Start starts (or resumes) the passed color's (player/side) clock.
+func (c *Clock) Start(color Color) {
+	if c.running != Color_None {
+		c.Stop()
+	}
+
+	c.running = color
+	c.turnStartedAt = c.now()
+}
+
+// Stop stops the running clock, if any.
+func (c *Clock) Stop() {
+	if c.running == Color_None {
+		return
+	}
+
+	c.remaining[c.running] = c.Remaining(c.running)
+	c.running = Color_None
+}
+
+// Running returns the color (player/side) whose clock is running.
+//
+// If the clock is stopped, it will return Color_None.
+func (c *Clock) Running() Color {
+	return c.running
+}
+
+// Remaining returns the remaining time of the passed color (player/side),
+// which will be negative if the player ran out of time.
+func (c *Clock) Remaining(color Color) time.Duration {
+	if color != c.running {
+		return c.remaining[color]
+	}
+
+	return c.remaining[color] - c.chargedTime(c.now().Sub(c.turnStartedAt))
+}
+
+// Stage returns the index of the time control stage the passed
+// color (player/side) is currently in.
+func (c *Clock) Stage(color Color) int {
+	return c.stage[color]
+}
+
+// Flagged returns the color (player/side) that ran out of time.
+//
+// If no player ran out of time, it will return Color_None.
+func (c *Clock) Flagged() Color {
+	if c.flaggedColor == Color_None && c.running != Color_None && c.Remaining(c.running) <= 0 {
+		c.flaggedColor = c.running
+	}
+
+	return c.flaggedColor
+}
+
+// chargedTime returns the time that is subtracted from the clock after
+// spending elapsed in a movement.
+func (c *Clock) chargedTime(elapsed time.Duration) time.Duration {
+	if c.timeControl.Mode == IncrementMode_Delay {
+		delay := c.currentStage(c.running).Increment
+		if elapsed <= delay {
+			return 0
+		}
+		return elapsed - delay
+	}
+
+	return elapsed
+}
+
+func (c *Clock) currentStage(color Color) TimeControlStage {
+	if c.stage[color] >= len(c.timeControl.Stages) {
+		return c.timeControl.Stages[len(c.timeControl.Stages)-1]
+	}
+	return c.timeControl.Stages[c.stage[color]]
+}
+
+// press ends the running color's turn, applying the increment and stage
+// changes, and starts the opponent's clock.
+//
+// If the running color ran out of time, the clock is stopped and it will
+// return false.
+func (c *Clock) press() (MovementTime, bool) {
+	color := c.running
+	now := c.now()
+	elapsed := now.Sub(c.turnStartedAt)
+	stage := c.currentStage(color)
+
+	c.remaining[color] -= c.chargedTime(elapsed)
+	if c.remaining[color] <= 0 {
+		c.running = Color_None
+		c.flaggedColor = color
+		return MovementTime{}, false
+	}
+
+	switch c.timeControl.Mode {
+	case IncrementMode_Fischer:
+		c.remaining[color] += stage.Increment
+	case IncrementMode_Bronstein:
+		c.remaining[color] += min(elapsed, stage.Increment)
+	}
+
+	c.stageMovements[color]++
+	if stage.Movements > 0 && c.stageMovements[color] == stage.Movements {
+		if c.stage[color] < len(c.timeControl.Stages)-1 {
+			c.stage[color]++
+		}
+		c.stageMovements[color] = 0
+		c.remaining[color] += c.currentStage(color).Time
+	}
+
+	c.running = color.Opposite()
+	c.turnStartedAt = now
+
+	return MovementTime{
+		Timestamp: now,
+		Elapsed:   elapsed,
+		Remaining: c.remaining[color],
+	}, true
+}
+
+// SetClock attaches the passed clock to the game, and starts the clock of
+// the player to move. Once attached, the clock is pressed after each movement,
+// and the game ends if a player runs out of time, once detected by a movement
+// or by CheckTimeout().
+//
+// A player running out of time loses the game, unless the opponent has
+// insufficient material to checkmate, in which case the game is drawn.
+func (g *Game) SetClock(clock *Clock) {
+	g.clock = clock
+
+	if clock != nil && g.outcome == Outcome_None {
+		clock.Start(g.currentPosition.playerToMove)
+	}
+}
+
+// Clock returns the game's clock.
+//
+// For non-timed games, it will return nil.
+func (g *Game) Clock() *Clock {
+	return g.clock
+}
+
+// MovementTimeHistory returns a slice with the clock data of each Movement
+// made in the game, in the same order as MovementHistory().
+//
+// Movements made without a running clock have an empty MovementTime.
+func (g *Game) MovementTimeHistory() []MovementTime {
+	return g.movementTimes
+}
+
+// CheckTimeout ends the game if the player to move ran out of time, and
+// reports whether it did.
+//
+// A timeout is detected when a movement is made, or when CheckTimeout is
+// called, so a UI showing the clock should call it periodically.
+//
+// Example:
+//
+//	for range time.Tick(100 * time.Millisecond) {
+//		if game.CheckTimeout() {
+//			fmt.Println(game.Outcome()) // "Timeout: White wins"
+//		}
+//	}
+func (g *Game) CheckTimeout() bool {
+	if g.clock == nil || g.outcome != Outcome_None {
+		return false
+	}
+
+	flaggedColor := g.clock.Flagged()
+	if flaggedColor == Color_None {
+		return false
+	}
+
+	if g.currentPosition.HasInsufficientMaterial(flaggedColor.Opposite()) {
+		g.Terminate(Outcome_Draw_Timeout)
+	} else {
+		g.Terminate(NewOutcome(Method_Timeout, flaggedColor.Opposite()))
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package chess
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeTime struct {
+	now time.Time
+}
+
+func (ft *fakeTime) Now() time.Time {
+	return ft.now
+}
+
+func (ft *fakeTime) Advance(d time.Duration) {
+	ft.now = ft.now.Add(d)
+}
+
+func TestParseTimeControl(t *testing.T) {
+	for _, text := range []string{"300", "180+2", "40/5400+30:1800+30", "40/7200:20/3600:900+30", "0.5+0.1"} {
+		timeControl, err := ParseTimeControl(text)
+		if err != nil || timeControl.String() != text {
+			t.Errorf("Parsing %q: got %q, %v", text, timeControl.String(), err)
+		}
+	}
+
+	for _, text := range []string{"", "abc", "40/", "5400:40/1800", "0/300", "300+-1"} {
+		if _, err := ParseTimeControl(text); err == nil {
+			t.Errorf("Expected an error parsing %q", text)
+		}
+	}
+}
+
+func TestClockIncrementModes(t *testing.T) {
+	tests := []struct {
+		mode      IncrementMode
+		elapsed   time.Duration
+		remaining time.Duration
+	}{
+		{IncrementMode_Fischer, 3 * time.Second, 62 * time.Second},
+		{IncrementMode_Delay, 3 * time.Second, 60 * time.Second},
+		{IncrementMode_Delay, 8 * time.Second, 57 * time.Second},
+		{IncrementMode_Bronstein, 3 * time.Second, 60 * time.Second},
+		{IncrementMode_Bronstein, 8 * time.Second, 57 * time.Second},
+	}
+
+	for _, test := range tests {
+		fake := &fakeTime{now: time.Unix(0, 0)}
+		timeControl := TimeControl{Stages: []TimeControlStage{{Time: time.Minute, Increment: 5 * time.Second}}, Mode: test.mode}
+		clock, _ := NewClock(timeControl, fake.Now)
+
+		game, _ := NewGame("")
+		game.SetClock(clock)
+
+		fake.Advance(test.elapsed)
+		game.MakeMovementAlgebraic("e2e4")
+
+		if clock.Remaining(Color_White) != test.remaining || clock.Running() != Color_Black {
+			t.Errorf("Mode %d, elapsed %s: expected %s remaining, got %s", test.mode, test.elapsed, test.remaining, clock.Remaining(Color_White))
+		}
+
+		history := game.MovementTimeHistory()
+		if len(history) != 1 || history[0].Elapsed != test.elapsed || history[0].Remaining != test.remaining || !history[0].Timestamp.Equal(fake.now) {
+			t.Errorf("Unexpected movement time history: %v", history)
+		}
+	}
+}
+
+func TestClockStagesAndTimeout(t *testing.T) {
+	fake := &fakeTime{now: time.Unix(0, 0)}
+	timeControl, _ := ParseTimeControl("2/60:30")
+	clock, _ := NewClock(timeControl, fake.Now)
+
+	game, _ := NewGame("")
+	game.SetClock(clock)
+
+	for _, algebraic := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		fake.Advance(10 * time.Second)
+		if err := game.MakeMovementAlgebraic(algebraic); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	if clock.Stage(Color_White) != 1 || clock.Remaining(Color_White) != 70*time.Second {
+		t.Errorf("Expected white to be in the second stage with 70s, got %d, %s", clock.Stage(Color_White), clock.Remaining(Color_White))
+	}
+
+	// Undoing restores black's time, but white is charged for its thinking time
+	fake.Advance(5 * time.Second)
+	game.UndoMovement()
+	if clock.Stage(Color_Black) != 0 || clock.Remaining(Color_Black) != 50*time.Second || clock.Running() != Color_Black {
+		t.Errorf("Expected undo to restore black's clock, got %d, %s", clock.Stage(Color_Black), clock.Remaining(Color_Black))
+	}
+	if clock.Stage(Color_White) != 1 || clock.Remaining(Color_White) != 65*time.Second {
+		t.Errorf("Expected undo to keep white's used time, got %d, %s", clock.Stage(Color_White), clock.Remaining(Color_White))
+	}
+	game.RedoMovement()
+
+	// Timeouts are only detected by movements or CheckTimeout
+	fake.Advance(71 * time.Second)
+	if game.Outcome() != Outcome_None {
+		t.Errorf("Expected Outcome to not detect the timeout, got %s", game.Outcome())
+	}
+	if !game.CheckTimeout() || game.Outcome() != Outcome_Timeout_Black {
+		t.Errorf("Expected black to win on time, got %s", game.Outcome())
+	}
+	if clock.Running() != Color_None || clock.Flagged() != Color_White {
+		t.Errorf("Expected the clock to stop after running out of time")
+	}
+
+	// No movement is accepted once the game ended on time
+	for _, algebraic := range []string{"g1f3", "g1h3"} {
+		if err := game.MakeMovementAlgebraic(algebraic); err == nil || err.Error() != "The player to move ran out of time." {
+			t.Errorf("Expected %s to be rejected after the timeout, got %v", algebraic, err)
+		}
+	}
+	if len(game.MovementHistory()) != 4 || game.Outcome() != Outcome_Timeout_Black {
+		t.Errorf("Expected the game to stay as it was, got %d movements and %s", len(game.MovementHistory()), game.Outcome())
+	}
+
+	// A flag fall against a lone king is a draw
+	fake = &fakeTime{now: time.Unix(0, 0)}
+	clock, _ = NewClock(TimeControl{Stages: []TimeControlStage{{Time: time.Second}}}, fake.Now)
+	game, _ = NewGame("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	game.SetClock(clock)
+	fake.Advance(2 * time.Second)
+	if err := game.MakeMovementAlgebraic("a1a2"); err == nil || game.Outcome() != Outcome_Draw_Timeout {
+		t.Errorf("Expected a draw by timeout against insufficient material, got %s", game.Outcome())
+	}
+	if err := game.MakeMovementAlgebraic("a1a2"); err == nil || len(game.MovementHistory()) != 0 {
+		t.Errorf("Expected the movement to be rejected again after the timeout, got %v", err)
+	}
+
+	// A knight can't mate a king blocked by queens, but it can with rooks
+	for fen, outcome := range map[string]Outcome{
+		"kq6/8/1K6/8/8/2N5/8/8 b - - 0 1": Outcome_Draw_Timeout,
+		"kr6/8/1K6/8/8/2N5/8/8 b - - 0 1": Outcome_Timeout_White,
+	} {
+		fake = &fakeTime{now: time.Unix(0, 0)}
+		clock, _ = NewClock(TimeControl{Stages: []TimeControlStage{{Time: time.Second}}}, fake.Now)
+		game, _ = NewGame(fen)
+		game.SetClock(clock)
+		fake.Advance(2 * time.Second)
+		if game.CheckTimeout(); game.Outcome() != outcome {
+			t.Errorf("Expected %s on a flag fall in %q, got %s", outcome, fen, game.Outcome())
+		}
+	}
+}
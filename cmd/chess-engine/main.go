@@ -0,0 +1,36 @@
+// Command chess-engine runs the built-in search engine through the standard
+// input and output, to be used from any UCI GUI, or from XBoard compatible
+// GUIs with the -xboard flag.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/keelus/chess/cecp"
+	"github.com/keelus/chess/engine"
+	"github.com/keelus/chess/uci"
+)
+
+func main() {
+	xboard := flag.Bool("xboard", false, "use the XBoard (CECP) protocol instead of UCI")
+	san := flag.Bool("san", false, "send movements in Standard Algebraic Notation (XBoard only)")
+	flag.Parse()
+
+	var err error
+	if *xboard {
+		server := cecp.NewServer("keelus/chess", engine.NewUCISearcher())
+		if *san {
+			server.SetNotation(cecp.Notation_SAN)
+		}
+		err = server.Serve(os.Stdin, os.Stdout)
+	} else {
+		err = uci.NewServer("keelus/chess", "keelus", engine.NewUCISearcher()).Serve(os.Stdin, os.Stdout)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+// Command epd-suite runs an EPD test suite, such as WAC or STS, against the
+// built-in search engine, and prints the report of the results.
+//
+// Example:
+//
+//	epd-suite -movetime 1s wac.epd
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/keelus/chess/engine"
+	"github.com/keelus/chess/epdsuite"
+)
+
+func main() {
+	depth := flag.Int("depth", 0, "the maximum depth of each search")
+	nodes := flag.Uint64("nodes", 0, "the maximum nodes of each search")
+	moveTime := flag.Duration("movetime", 0, "the maximum time of each search")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: epd-suite [flags] <file.epd>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	epds, err := epdsuite.LoadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Interrupting prints the report of the positions searched so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := epdsuite.Run(ctx, engine.NewUCISearcher(), epds, epdsuite.Options{
+		Depth:    *depth,
+		Nodes:    *nodes,
+		MoveTime: *moveTime,
+		OnResult: func(result epdsuite.Result) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", result.ID, result.SAN)
+		},
+	})
+	fmt.Print(report)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,240 @@
+// Package engine implements a chess engine on top of the chess package's
+// movement generation: a negamax alpha-beta search with iterative deepening,
+// quiescence search, movement ordering and a transposition table.
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// The maximum search depth, in plies.
+const MaxDepth = 64
+
+const (
+	infinityScore = 32000
+	mateScore     = 31000
+	mateThreshold = mateScore - MaxDepth*2
+)
+
+// Score represents a position's evaluation in centipawns, from the
+// point of view of the player to move.
+//
+// Mate scores are near ±31000, so they are higher (or lower) than any
+// other score. Use IsMate() and MateIn() to inspect them.
+type Score int
+
+// IsMate reports whether the score is a forced checkmate, for any player.
+func (s Score) IsMate() bool {
+	return s > mateThreshold || s < -mateThreshold
+}
+
+// MateIn returns the amount of movements (not plies) to checkmate, negative
+// if the player to move is getting checkmated.
+//
+// If the score is not a mate score, it will return 0.
+func (s Score) MateIn() int {
+	if s > mateThreshold {
+		return (mateScore - int(s) + 1) / 2
+	} else if s < -mateThreshold {
+		return -(mateScore + int(s)) / 2
+	}
+	return 0
+}
+
+// Limits represents the limits of a search. Zero values mean no limit. If no
+// limits are set, the search ends at MaxDepth or once the context is done.
+type Limits struct {
+	Depth    int           // Maximum depth, in plies
+	Nodes    uint64        // Maximum amount of nodes
+	MoveTime time.Duration // Maximum search time
+
+	// SearchMovements restricts the search to these movements of the
+	// position. If empty, all the legal movements are searched.
+	SearchMovements []chess.Movement
+}
+
+// Info represents the result of a completed iterative deepening iteration.
+type Info struct {
+	Depth    int
+	SelDepth int // Maximum depth reached, including the quiescence search
+	Score    Score
+	Nodes    uint64
+	Time     time.Duration
+	PV       []chess.Movement // Principal variation
+}
+
+// Result represents the result of a search.
+type Result struct {
+	BestMove chess.Movement
+	Score    Score
+	PV       []chess.Movement // Principal variation, starting with BestMove
+	Depth    int              // Depth of the last completed iteration
+	Nodes    uint64
+}
+
+// Engine represents a chess engine, that keeps its transposition table
+// and movement ordering data between searches.
+//
+// Note: If you intend in creating a new Engine, use New() function. An
+// Engine is not safe for concurrent use.
+type Engine struct {
+	tt       transpositionTable
+	killers  [MaxDepth + 1][2]chess.Movement
+	history  [chess.COLOR_AMOUNT + 1][64][64]int
+	pvTable  [MaxDepth + 1][MaxDepth + 1]chess.Movement
+	pvLength [MaxDepth + 1]int
+
+	// OnInfo, if set, is called after each completed iteration.
+	OnInfo func(Info)
+
+	// Search state
+	ctx         context.Context
+	limits      Limits
+	start       time.Time
+	nodes       uint64
+	selDepth    int
+	stopped     bool
+	path        []uint64 // Hashes of the game and searched positions, to detect repetitions
+	rootHistory int      // Amount of hashes of the game in path
+}
+
+// New creates and returns a new Engine, with a transposition table of the
+// passed size, in megabytes.
+func New(hashSize int) *Engine {
+	return &Engine{
+		tt: newTranspositionTable(hashSize),
+	}
+}
+
+// Clear clears the transposition table and movement ordering data, for
+// example, before starting a new game.
+func (e *Engine) Clear() {
+	e.tt.clear()
+	e.killers = [MaxDepth + 1][2]chess.Movement{}
+	e.history = [chess.COLOR_AMOUNT + 1][64][64]int{}
+}
+
+// Search searches the best movement of the game's current position, until
+// any of the limits is reached, or the context is done. Repetitions of
+// earlier positions of the game are scored as draws.
+//
+// The returned result is the one of the last completed iteration. If the
+// position has no legal movements, the result has no BestMove nor PV.
+//
+// Example:
+//
+//	e := engine.New(16)
+//	result := e.Search(ctx, &game, engine.Limits{MoveTime: time.Second})
+//	game.MakeMovement(result.BestMove)
+func (e *Engine) Search(ctx context.Context, game *chess.Game, limits Limits) Result {
+	e.ctx = ctx
+	e.limits = limits
+	e.start = time.Now()
+	e.nodes = 0
+	e.stopped = false
+
+	e.path = e.path[:0]
+	for i := 0; i < game.CurrentPositionIndex(); i++ {
+		position, _ := game.PositionAtIndex(i)
+		e.path = append(e.path, position.Hash())
+	}
+	e.rootHistory = len(e.path)
+
+	// Older history is less relevant
+	for color := range e.history {
+		for from := range e.history[color] {
+			for to := range e.history[color][from] {
+				e.history[color][from][to] /= 8
+			}
+		}
+	}
+
+	maxDepth := MaxDepth
+	if limits.Depth > 0 {
+		maxDepth = min(limits.Depth, MaxDepth)
+	}
+
+	position := game.CurrentPosition()
+	var result Result
+
+	for depth := 1; depth <= maxDepth && ctx.Err() == nil; depth++ {
+		e.selDepth = 0
+		score := e.negamax(&position, depth, 0, -infinityScore, infinityScore)
+
+		// Results of interrupted iterations are discarded
+		if e.stopped {
+			break
+		}
+
+		pv := make([]chess.Movement, e.pvLength[0])
+		copy(pv, e.pvTable[0][:e.pvLength[0]])
+
+		result = Result{
+			Score: Score(score),
+			PV:    pv,
+			Depth: depth,
+			Nodes: e.nodes,
+		}
+		if len(pv) > 0 {
+			result.BestMove = pv[0]
+		}
+
+		if e.OnInfo != nil {
+			e.OnInfo(Info{
+				Depth:    depth,
+				SelDepth: e.selDepth,
+				Score:    Score(score),
+				Nodes:    e.nodes,
+				Time:     time.Since(e.start),
+				PV:       pv,
+			})
+		}
+
+		// No need to search deeper once a forced mate is found, or if there are no movements
+		if Score(score).IsMate() && depth > mateScore-abs(score) || len(pv) == 0 {
+			break
+		}
+	}
+
+	// Always return a movement if there is any
+	if len(result.PV) == 0 {
+		movements := position.LegalMovements()
+		if len(limits.SearchMovements) > 0 {
+			movements = filterMovements(movements, limits.SearchMovements)
+		}
+		if len(movements) > 0 {
+			result.BestMove = movements[0]
+			result.PV = []chess.Movement{movements[0]}
+		}
+	}
+	result.Nodes = e.nodes
+
+	return result
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// checkLimits sets stopped if any limit was reached. The clock and context
+// are only checked every 1024 nodes.
+func (e *Engine) checkLimits() {
+	if e.limits.Nodes > 0 && e.nodes >= e.limits.Nodes {
+		e.stopped = true
+		return
+	}
+
+	if e.nodes%1024 != 0 {
+		return
+	}
+
+	if e.ctx.Err() != nil || (e.limits.MoveTime > 0 && time.Since(e.start) >= e.limits.MoveTime) {
+		e.stopped = true
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+func TestSearchMate(t *testing.T) {
+	tests := []struct {
+		fen    string
+		depth  int
+		best   string
+		mateIn int
+	}{
+		{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", 3, "d1d8", 1},                               // Back rank mate
+		{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", 3, "h5f7", 1}, // Scholar's mate
+		{"2r3k1/5ppp/8/8/8/8/3R1PPP/3R2K1 w - - 0 1", 5, "d2d8", 2},
+		{"6k1/8/8/8/8/1r6/r7/7K w - - 0 1", 4, "h1g1", -1}, // Being mated
+	}
+
+	for _, test := range tests {
+		game, _ := chess.NewGame(test.fen)
+		result := New(1).Search(context.Background(), &game, Limits{Depth: test.depth})
+
+		if test.best != "" && result.BestMove.Algebraic() != test.best {
+			t.Errorf("Expected best movement of %q to be %s, got %s", test.fen, test.best, result.BestMove.Algebraic())
+		}
+		if !result.Score.IsMate() || result.Score.MateIn() != test.mateIn {
+			t.Errorf("Expected %q to be mate in %d, got score %d", test.fen, test.mateIn, result.Score)
+		}
+		if len(result.PV) == 0 || result.PV[0] != result.BestMove {
+			t.Errorf("Expected the PV of %q to start with the best movement", test.fen)
+		}
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	game, _ := chess.NewGame("")
+	e := New(1)
+
+	result := e.Search(context.Background(), &game, Limits{Nodes: 5000})
+	if result.Nodes > 5000 || !game.IsMovementLegal(result.BestMove) {
+		t.Errorf("Expected a legal movement within 5000 nodes, got %s in %d nodes", result.BestMove.Algebraic(), result.Nodes)
+	}
+
+	start := time.Now()
+	result = e.Search(context.Background(), &game, Limits{MoveTime: 100 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > time.Second || !game.IsMovementLegal(result.BestMove) {
+		t.Errorf("Expected a legal movement within 100ms, got %s in %s", result.BestMove.Algebraic(), elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var depths []int
+	e.OnInfo = func(info Info) {
+		depths = append(depths, info.Depth)
+		if info.Depth == 2 {
+			cancel()
+		}
+	}
+	result = e.Search(ctx, &game, Limits{})
+	if result.Depth != 2 || len(depths) != result.Depth || !game.IsMovementLegal(result.BestMove) {
+		t.Errorf("Expected the search to stop after cancelling, got depth %d (infos %v)", result.Depth, depths)
+	}
+}
+
+func TestSearchDraw(t *testing.T) {
+	// Black is a queen down, but can repeat a previous position
+	game, _ := chess.NewGame("7k/8/8/8/8/8/8/1Q4K1 b - - 0 1")
+	for _, movement := range []string{"h8g8", "b1c1", "g8h8", "c1b1"} {
+		game.MakeMovementAlgebraic(movement)
+	}
+
+	result := New(1).Search(context.Background(), &game, Limits{Depth: 4})
+	if result.Score != 0 || result.BestMove.Algebraic() != "h8g8" {
+		t.Errorf("Expected to repeat the position with h8g8, got %s (score %d)", result.BestMove.Algebraic(), result.Score)
+	}
+
+	stalemate, _ := chess.NewGame("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	result = New(1).Search(context.Background(), &stalemate, Limits{Depth: 4})
+	if result.Score != 0 || len(result.PV) != 0 {
+		t.Errorf("Expected the stalemate to be scored as a draw without movements, got %d %v", result.Score, result.PV)
+	}
+}
@@ -0,0 +1,11 @@
+package engine
+
+import (
+	"github.com/keelus/chess"
+)
+
+// evaluate returns the static evaluation of the position, in centipawns,
+// from the point of view of the player to move.
+func evaluate(position *chess.Position) int {
+	return chess.Evaluate(*position)
+}
@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"slices"
+
+	"github.com/keelus/chess"
+)
+
+// Movement ordering scores. Captures and promotions go first, then the
+// killer movements, and then the rest, by their history score.
+const (
+	orderScore_TT       = 1 << 30
+	orderScore_Capture  = 1 << 20
+	orderScore_Killer   = 900_000
+	orderScore_Killer2  = 800_000
+	orderScore_MaxQuiet = 700_000
+)
+
+// negamax returns the score of the position, searching its movements
+// up to the passed depth, with alpha-beta pruning.
+func (e *Engine) negamax(position *chess.Position, depth, ply, alpha, beta int) int {
+	e.pvLength[ply] = 0
+
+	e.nodes++
+	e.checkLimits()
+	if e.stopped {
+		return 0
+	}
+
+	if ply > 0 && e.isDraw(position) {
+		return 0
+	}
+
+	if ply >= MaxDepth {
+		return evaluate(position)
+	}
+
+	// Check extension
+	isChecked := position.IsChecked()
+	if isChecked {
+		depth++
+	}
+
+	if depth <= 0 {
+		return e.quiescence(position, ply, alpha, beta)
+	}
+
+	hash := position.Hash()
+	var ttMovement chess.Movement
+	if entry, found := e.tt.probe(hash); found {
+		ttMovement = entry.movement
+
+		if ply > 0 && int(entry.depth) >= depth {
+			score := scoreFromTT(int(entry.score), ply)
+			switch {
+			case entry.bound == ttBound_Exact,
+				entry.bound == ttBound_Lower && score >= beta,
+				entry.bound == ttBound_Upper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	movements := position.LegalMovements()
+	if ply == 0 && len(e.limits.SearchMovements) > 0 {
+		movements = filterMovements(movements, e.limits.SearchMovements)
+	}
+
+	if len(movements) == 0 {
+		if isChecked {
+			return -mateScore + ply
+		}
+		return 0
+	}
+
+	orderScores := e.orderScores(movements, ttMovement, ply, position.Turn())
+
+	e.path = append(e.path, hash)
+	defer func() { e.path = e.path[:len(e.path)-1] }()
+
+	bestScore := -infinityScore
+	var bestMovement chess.Movement
+	bound := ttBound_Upper
+
+	for i := range movements {
+		movement := pickMovement(movements, orderScores, i)
+
+		child := position.Apply(movement)
+		score := -e.negamax(&child, depth-1, ply+1, -beta, -alpha)
+		if e.stopped {
+			return 0
+		}
+
+		if score <= bestScore {
+			continue
+		}
+		bestScore = score
+		bestMovement = movement
+
+		if score <= alpha {
+			continue
+		}
+		alpha = score
+		bound = ttBound_Exact
+		e.updatePV(ply, movement)
+
+		if score >= beta {
+			bound = ttBound_Lower
+			if !movement.IsTakingPiece() && !movement.IsPawnPromotion() {
+				e.storeKiller(ply, movement)
+				from, to := movement.FromSquare(), movement.ToSquare()
+				e.history[position.Turn()][from.I*8+from.J][to.I*8+to.J] += depth * depth
+			}
+			break
+		}
+	}
+
+	e.tt.store(hash, bestMovement, bestScore, depth, ply, bound)
+	return bestScore
+}
+
+// quiescence returns the score of the position once there are no captures
+// nor promotions left, to avoid evaluating positions in the middle of an
+// exchange.
+func (e *Engine) quiescence(position *chess.Position, ply, alpha, beta int) int {
+	e.pvLength[ply] = 0
+	e.selDepth = max(e.selDepth, ply)
+
+	e.nodes++
+	e.checkLimits()
+	if e.stopped {
+		return 0
+	}
+
+	standPat := evaluate(position)
+	if ply >= MaxDepth || standPat >= beta {
+		return standPat
+	}
+	alpha = max(alpha, standPat)
+
+	movements := position.LegalMovements()
+	tactical := movements[:0]
+	for _, movement := range movements {
+		if movement.IsTakingPiece() || movement.IsPawnPromotion() {
+			tactical = append(tactical, movement)
+		}
+	}
+	orderScores := e.orderScores(tactical, chess.Movement{}, ply, position.Turn())
+
+	for i := range tactical {
+		movement := pickMovement(tactical, orderScores, i)
+
+		child := position.Apply(movement)
+		score := -e.quiescence(&child, ply+1, -beta, -alpha)
+		if e.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+
+	return alpha
+}
+
+// filterMovements returns the movements that are in allowed.
+func filterMovements(movements, allowed []chess.Movement) []chess.Movement {
+	filtered := movements[:0]
+	for _, movement := range movements {
+		if slices.Contains(allowed, movement) {
+			filtered = append(filtered, movement)
+		}
+	}
+	return filtered
+}
+
+// isDraw reports whether the position is a draw by the fifty-move rule,
+// insufficient material, or repetition of a previous position.
+func (e *Engine) isDraw(position *chess.Position) bool {
+	halfmoveClock := int(position.HalfmoveClock())
+	if halfmoveClock >= 100 {
+		return true
+	}
+
+	if position.HasInsufficientMaterial(chess.Color_White) && position.HasInsufficientMaterial(chess.Color_Black) {
+		return true
+	}
+
+	// Positions can only repeat since the last capture or pawn movement,
+	// and with the same player to move
+	hash := position.Hash()
+	for i := len(e.path) - 2; i >= 0 && i >= len(e.path)-halfmoveClock; i -= 2 {
+		if e.path[i] == hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orderScores returns the ordering score of each movement.
+func (e *Engine) orderScores(movements []chess.Movement, ttMovement chess.Movement, ply int, turn chess.Color) []int {
+	scores := make([]int, len(movements))
+
+	for i, movement := range movements {
+		switch {
+		case movement == ttMovement:
+			scores[i] = orderScore_TT
+		case movement.IsTakingPiece() || movement.IsPawnPromotion():
+			// MVV-LVA: most valuable victim first, then least valuable attacker
+			scores[i] = orderScore_Capture - chess.MaterialValue(movement.MovingPiece().Kind)/10
+			if takingPiece, err := movement.TakingPiece(); err == nil {
+				scores[i] += chess.MaterialValue(takingPiece.Kind) * 10
+			}
+			if kind, err := movement.PawnPromotion(); err == nil {
+				scores[i] += chess.MaterialValue(kind) * 10
+			}
+		case movement == e.killers[ply][0]:
+			scores[i] = orderScore_Killer
+		case movement == e.killers[ply][1]:
+			scores[i] = orderScore_Killer2
+		default:
+			from, to := movement.FromSquare(), movement.ToSquare()
+			scores[i] = min(e.history[turn][from.I*8+from.J][to.I*8+to.J], orderScore_MaxQuiet)
+		}
+	}
+
+	return scores
+}
+
+// pickMovement moves the best scored movement from index onwards to index,
+// and returns it.
+func pickMovement(movements []chess.Movement, scores []int, index int) chess.Movement {
+	best := index
+	for i := index + 1; i < len(movements); i++ {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	movements[index], movements[best] = movements[best], movements[index]
+	scores[index], scores[best] = scores[best], scores[index]
+	return movements[index]
+}
+
+func (e *Engine) storeKiller(ply int, movement chess.Movement) {
+	if e.killers[ply][0] != movement {
+		e.killers[ply][1] = e.killers[ply][0]
+		e.killers[ply][0] = movement
+	}
+}
+
+// updatePV sets the principal variation of the ply to the movement,
+// followed by the principal variation of the next ply.
+func (e *Engine) updatePV(ply int, movement chess.Movement) {
+	e.pvTable[ply][0] = movement
+	copy(e.pvTable[ply][1:], e.pvTable[ply+1][:e.pvLength[ply+1]])
+	e.pvLength[ply] = e.pvLength[ply+1] + 1
+}
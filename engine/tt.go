@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"unsafe"
+
+	"github.com/keelus/chess"
+)
+
+type ttBound uint8
+
+const (
+	ttBound_Exact ttBound = iota
+	ttBound_Lower         // The score is at least the stored one (beta cutoff)
+	ttBound_Upper         // The score is at most the stored one (no movement raised alpha)
+)
+
+type ttEntry struct {
+	hash     uint64
+	movement chess.Movement
+	score    int32
+	depth    int8
+	bound    ttBound
+	used     bool
+}
+
+// transpositionTable stores the results of searched positions, keyed by
+// their Zobrist hash. Entries are always replaced.
+type transpositionTable struct {
+	entries []ttEntry
+}
+
+func newTranspositionTable(megabytes int) transpositionTable {
+	size := max(megabytes<<20/int(unsafe.Sizeof(ttEntry{})), 1)
+	return transpositionTable{
+		entries: make([]ttEntry, size),
+	}
+}
+
+func (t *transpositionTable) probe(hash uint64) (ttEntry, bool) {
+	entry := t.entries[hash%uint64(len(t.entries))]
+	return entry, entry.used && entry.hash == hash
+}
+
+func (t *transpositionTable) store(hash uint64, movement chess.Movement, score, depth, ply int, bound ttBound) {
+	t.entries[hash%uint64(len(t.entries))] = ttEntry{
+		hash:     hash,
+		movement: movement,
+		score:    int32(scoreToTT(score, ply)),
+		depth:    int8(depth),
+		bound:    bound,
+		used:     true,
+	}
+}
+
+func (t *transpositionTable) clear() {
+	clear(t.entries)
+}
+
+// Mate scores are stored relative to the position instead of the root, so
+// they are still correct when the position is reached through other paths.
+func scoreToTT(score, ply int) int {
+	if score > mateThreshold {
+		return score + ply
+	} else if score < -mateThreshold {
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score, ply int) int {
+	if score > mateThreshold {
+		return score - ply
+	} else if score < -mateThreshold {
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/uci"
+)
+
+// Default size of the transposition table, in megabytes.
+const defaultHashSize = 16
+
+// UCISearcher adapts an Engine to the uci.Searcher interface, so it can be
+// used as a UCI engine via uci.Server.
+//
+// It has the "Hash" and "Clear Hash" options, and manages the time of
+// searches with clocks ("wtime", "btime", ...) by itself.
+type UCISearcher struct {
+	engine *Engine
+}
+
+// NewUCISearcher creates and returns a new UCISearcher, with its own Engine.
+//
+// Example:
+//
+//	server := uci.NewServer("Chess", "keelus", engine.NewUCISearcher())
+//	server.Serve(os.Stdin, os.Stdout)
+func NewUCISearcher() *UCISearcher {
+	return &UCISearcher{
+		engine: New(defaultHashSize),
+	}
+}
+
+// Options returns the options of the engine.
+func (s *UCISearcher) Options() []uci.Option {
+	return []uci.Option{
+		{Name: "Hash", Type: "spin", Default: strconv.Itoa(defaultHashSize), Min: 1, Max: 4096},
+		{Name: "Clear Hash", Type: "button"},
+	}
+}
+
+// SetOption sets the value of an option of the engine.
+func (s *UCISearcher) SetOption(name, value string) error {
+	switch name {
+	case "Hash":
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > 4096 {
+			return fmt.Errorf("The Hash size \"%s\" is not valid.", value)
+		}
+		s.engine.tt = newTranspositionTable(size)
+	case "Clear Hash":
+		s.engine.Clear()
+	default:
+		return fmt.Errorf("The option \"%s\" does not exist.", name)
+	}
+	return nil
+}
+
+// NewGame clears the engine's data of the previous game.
+func (s *UCISearcher) NewGame() {
+	s.engine.Clear()
+}
+
+// Search searches the game's current position with the engine.
+func (s *UCISearcher) Search(ctx context.Context, game *chess.Game, params uci.GoParams, onInfo func(uci.Info)) (uci.SearchResult, error) {
+	position := game.CurrentPosition()
+
+	limits := Limits{
+		Depth:    params.Depth,
+		Nodes:    params.Nodes,
+		MoveTime: params.MoveTime,
+	}
+	if params.Mate > 0 && limits.Depth == 0 {
+		limits.Depth = 2*params.Mate - 1
+	}
+	if !params.Infinite && !params.Ponder && limits.MoveTime == 0 {
+		limits.MoveTime = allocateTime(params, position.Turn())
+	}
+
+	for _, searchMove := range params.SearchMoves {
+		movement, err := uci.ParseMovement(position, searchMove)
+		if err != nil {
+			return uci.SearchResult{}, err
+		}
+		limits.SearchMovements = append(limits.SearchMovements, movement)
+	}
+
+	s.engine.OnInfo = func(info Info) {
+		if onInfo != nil {
+			onInfo(toUCIInfo(info))
+		}
+	}
+	defer func() { s.engine.OnInfo = nil }()
+
+	result := s.engine.Search(ctx, game, limits)
+
+	if len(result.PV) == 0 {
+		return uci.SearchResult{}, errors.New("The position has no legal movements.")
+	}
+
+	searchResult := uci.SearchResult{
+		BestMove: result.BestMove.Algebraic(),
+		Infos: []uci.Info{toUCIInfo(Info{
+			Depth: result.Depth,
+			Score: result.Score,
+			Nodes: result.Nodes,
+			PV:    result.PV,
+		})},
+	}
+	if len(result.PV) > 1 {
+		searchResult.Ponder = result.PV[1].Algebraic()
+	}
+
+	return searchResult, nil
+}
+
+// allocateTime returns the time to spend in a search with clocks, or 0
+// (no limit) if the clock of the player to move was not sent.
+func allocateTime(params uci.GoParams, turn chess.Color) time.Duration {
+	remaining, increment := params.WTime, params.WInc
+	if turn == chess.Color_Black {
+		remaining, increment = params.BTime, params.BInc
+	}
+
+	if remaining == 0 {
+		return 0
+	}
+
+	movesToGo := params.MovesToGo
+	if movesToGo == 0 {
+		movesToGo = 30
+	}
+
+	// Keep a margin for the communication with the GUI
+	margin := min(50*time.Millisecond, remaining/10)
+	allocated := remaining/time.Duration(movesToGo) + increment*3/4
+	return max(min(allocated, remaining-margin), time.Millisecond)
+}
+
+func toUCIInfo(info Info) uci.Info {
+	uciInfo := uci.Info{
+		Depth:    info.Depth,
+		SelDepth: info.SelDepth,
+		HasScore: true,
+		Nodes:    info.Nodes,
+		Time:     info.Time,
+		PV:       make([]string, len(info.PV)),
+	}
+
+	if info.Score.IsMate() {
+		uciInfo.Score = uci.Score{Mate: info.Score.MateIn(), IsMate: true}
+	} else {
+		uciInfo.Score = uci.Score{Centipawns: int(info.Score)}
+	}
+
+	if info.Time > 0 {
+		uciInfo.NPS = uint64(float64(info.Nodes) / info.Time.Seconds())
+	}
+
+	for i, movement := range info.PV {
+		uciInfo.PV[i] = movement.Algebraic()
+	}
+
+	return uciInfo
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/uci"
+)
+
+func TestUCISearcher(t *testing.T) {
+	searcher := NewUCISearcher()
+	if err := searcher.SetOption("Hash", "2"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := searcher.SetOption("Hash", "0"); err == nil {
+		t.Errorf("Expected an error with an invalid Hash size")
+	}
+
+	game, _ := chess.NewGame("6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1")
+
+	var infos []uci.Info
+	result, err := searcher.Search(context.Background(), &game, uci.GoParams{Mate: 1, SearchMoves: []string{"d1d8", "g1f1"}}, func(info uci.Info) {
+		infos = append(infos, info)
+	})
+	if err != nil || result.BestMove != "d1d8" || len(infos) == 0 || infos[len(infos)-1].Score != (uci.Score{Mate: 1, IsMate: true}) {
+		t.Errorf("Expected d1d8 with mate in 1, got %+v (%v) with infos %+v", result, err, infos)
+	}
+
+	result, err = searcher.Search(context.Background(), &game, uci.GoParams{SearchMoves: []string{"g1f1"}, Depth: 2}, nil)
+	if err != nil || result.BestMove != "g1f1" {
+		t.Errorf("Expected the search to be restricted to g1f1, got %+v (%v)", result, err)
+	}
+
+	if _, err := searcher.Search(context.Background(), &game, uci.GoParams{SearchMoves: []string{"e2e4"}}, nil); err == nil {
+		t.Errorf("Expected an error with an illegal search movement")
+	}
+}
+
+func TestAllocateTime(t *testing.T) {
+	params := uci.GoParams{WTime: time.Minute, BTime: 30 * time.Second, WInc: time.Second}
+
+	if allocated := allocateTime(params, chess.Color_White); allocated != 2*time.Second+750*time.Millisecond {
+		t.Errorf("Expected 2.75s for white, got %s", allocated)
+	}
+	if allocated := allocateTime(params, chess.Color_Black); allocated != time.Second {
+		t.Errorf("Expected 1s for black, got %s", allocated)
+	}
+	if allocated := allocateTime(uci.GoParams{WTime: 40 * time.Millisecond, WInc: time.Second}, chess.Color_White); allocated != 36*time.Millisecond {
+		t.Errorf("Expected the remaining time minus a margin, got %s", allocated)
+	}
+	if allocated := allocateTime(uci.GoParams{}, chess.Color_White); allocated != 0 {
+		t.Errorf("Expected no limit without clocks, got %s", allocated)
+	}
+}
@@ -0,0 +1,463 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Opcodes whose operands are strings, always written between quotes
+var epdStringOpcodes = map[string]bool{
+	"id": true, "c0": true, "c1": true, "c2": true, "c3": true, "c4": true, "c5": true, "c6": true, "c7": true, "c8": true, "c9": true,
+	"v0": true, "v1": true, "v2": true, "v3": true, "v4": true, "v5": true, "v6": true, "v7": true, "v8": true, "v9": true,
+}
+
+// Opcodes whose movements are made one after another, instead of all being
+// movements of the position
+var epdSequentialOpcodes = map[string]bool{"pv": true}
+
+// EPD represents a position in Extended Position Description: the first
+// four fields of a FEN (piece placement, active color, castling rights and
+// en passant square), followed by operations.
+//
+// Common opcodes are "bm" (best movements), "am" (movements to avoid),
+// "id" (identifier), "c0" to "c9" (comments), "acd" (analysis depth),
+// "ce" (centipawn evaluation), "pv" (principal variation), "hmvc"
+// (halfmove clock), "fmvn" (fullmove number) and "D1", "D2", ... (perft
+// node counts).
+//
+// Note: If you intend in creating a new EPD from a string, use ParseEPD() function.
+type EPD struct {
+	// Position is the position of the EPD. Its halfmove clock and fullmove
+	// counter are the ones of the "hmvc" and "fmvn" operations, if any.
+	Position Position
+
+	// Operations contains the operations of the EPD, in order.
+	Operations []EPDOperation
+
+	hasClocks  bool // Whether the position was written as a full FEN, with its clocks
+	isShredder bool // Whether the castling rights were written in Shredder-FEN
+}
+
+// EPDOperation represents an operation of an EPD: an opcode followed by
+// zero or more operands. String operands are stored without quotes, and
+// without the backslashes escaping their quotes and backslashes.
+type EPDOperation struct {
+	Opcode   string
+	Operands []string
+}
+
+// ParseEPD parses a position in Extended Position Description.
+//
+// For compatibility with perft suites, the position can also be a full FEN,
+// including the halfmove clock and fullmove number, and operations may be
+// preceded by a semicolon.
+//
+// If the EPD is invalid, it will return an empty EPD and the error.
+//
+// Examples:
+//
+//	ParseEPD(`r1bqk1r1/1p1p1n2/p1n2pN1/2p1b2Q/2P1Pp2/1PN5/PB4PP/R4RK1 w q - bm Rxf4; id "ERET 001 - Relief";`)
+//	ParseEPD("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1;D1 20;D2 400")
+func ParseEPD(epd string) (EPD, error) {
+	tokens, err := tokenizeEPD(epd)
+	if err != nil {
+		return EPD{}, err
+	}
+
+	if len(tokens) < 4 {
+		return EPD{}, errors.New("The provided EPD does not have the 4 position fields.")
+	}
+	for _, token := range tokens[:4] {
+		if token.isString || token.value == ";" {
+			return EPD{}, errors.New("The provided EPD does not have the 4 position fields.")
+		}
+	}
+
+	parsedEPD := EPD{
+		Operations: make([]EPDOperation, 0),
+	}
+	// Castling rights only written with files are kept in Shredder-FEN
+	parsedEPD.isShredder = tokens[2].value != "-" && !strings.ContainsAny(tokens[2].value, "KQkq")
+
+	fen := tokens[0].value + " " + tokens[1].value + " " + tokens[2].value + " " + tokens[3].value
+	tokens = tokens[4:]
+
+	// Opcodes begin with a letter, so two numbers are the FEN clocks
+	if len(tokens) >= 2 && isEPDNumber(tokens[0]) && isEPDNumber(tokens[1]) {
+		fen += " " + tokens[0].value + " " + tokens[1].value
+		parsedEPD.hasClocks = true
+		tokens = tokens[2:]
+	} else {
+		fen += " 0 1"
+	}
+
+	if parsedEPD.Position, err = newPositionFromFen(fen); err != nil {
+		return EPD{}, err
+	}
+
+	var operation *EPDOperation
+	for _, token := range tokens {
+		switch {
+		case !token.isString && token.value == ";":
+			operation = nil
+		case operation == nil:
+			if token.isString || !isEPDOpcode(token.value) {
+				return EPD{}, fmt.Errorf("The provided EPD has an invalid opcode \"%s\".", token.value)
+			}
+			parsedEPD.Operations = append(parsedEPD.Operations, EPDOperation{Opcode: token.value, Operands: make([]string, 0)})
+			operation = &parsedEPD.Operations[len(parsedEPD.Operations)-1]
+		default:
+			operation.Operands = append(operation.Operands, token.value)
+		}
+	}
+
+	if err := parsedEPD.applyClocks(); err != nil {
+		return EPD{}, err
+	}
+
+	return parsedEPD, nil
+}
+
+type epdToken struct {
+	value    string
+	isString bool
+}
+
+// tokenizeEPD splits an EPD in its fields, string operands and semicolons.
+func tokenizeEPD(epd string) ([]epdToken, error) {
+	tokens := make([]epdToken, 0)
+
+	for i := 0; i < len(epd); {
+		switch {
+		case unicode.IsSpace(rune(epd[i])):
+			i++
+		case epd[i] == ';':
+			tokens = append(tokens, epdToken{value: ";"})
+			i++
+		case epd[i] == '"':
+			value, length, err := readEPDString(epd[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, epdToken{value: value, isString: true})
+			i += length
+		default:
+			end := strings.IndexFunc(epd[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || r == ';' || r == '"'
+			})
+			if end == -1 {
+				end = len(epd) - i
+			}
+			tokens = append(tokens, epdToken{value: epd[i : i+end]})
+			i += end
+		}
+	}
+
+	return tokens, nil
+}
+
+// readEPDString reads the string operand at the beginning of the EPD, in
+// quotes, and returns it unescaped with the length it takes in the EPD.
+// Quotes and backslashes can be escaped with a backslash.
+func readEPDString(epd string) (string, int, error) {
+	var sb strings.Builder
+
+	for i := 1; i < len(epd); i++ {
+		switch {
+		case epd[i] == '"':
+			return sb.String(), i + 1, nil
+		case epd[i] == '\\' && i+1 < len(epd) && (epd[i+1] == '"' || epd[i+1] == '\\'):
+			i++
+		}
+		sb.WriteByte(epd[i])
+	}
+
+	return "", 0, errors.New("The provided EPD has an unterminated string.")
+}
+
+// writeEPDString returns the string operand in quotes, escaping its quotes
+// and backslashes.
+func writeEPDString(operand string) string {
+	operand = strings.ReplaceAll(operand, "\\", "\\\\")
+	return "\"" + strings.ReplaceAll(operand, "\"", "\\\"") + "\""
+}
+
+func isEPDNumber(token epdToken) bool {
+	_, err := strconv.ParseUint(token.value, 10, 0)
+	return !token.isString && err == nil
+}
+
+// isEPDOpcode reports whether the opcode is valid: a letter, followed by
+// letters, digits or underscores.
+func isEPDOpcode(opcode string) bool {
+	for i, r := range opcode {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') && (i == 0 || !(r >= '0' && r <= '9' || r == '_')) {
+			return false
+		}
+	}
+	return opcode != ""
+}
+
+// applyClocks sets the halfmove clock and fullmove counter of the position
+// to the ones of the "hmvc" and "fmvn" operations.
+func (e *EPD) applyClocks() error {
+	if _, ok := e.Operation("hmvc"); ok {
+		halfmoveClock, err := e.IntOperand("hmvc")
+		if err != nil || halfmoveClock < 0 || halfmoveClock > 255 {
+			return errors.New("The provided EPD does not have a valid hmvc operation.")
+		}
+		e.Position.halfmoveClock = uint8(halfmoveClock)
+	}
+
+	if _, ok := e.Operation("fmvn"); ok {
+		fullmoveCounter, err := e.IntOperand("fmvn")
+		if err != nil || fullmoveCounter < 1 {
+			return errors.New("The provided EPD does not have a valid fmvn operation.")
+		}
+		e.Position.fullmoveCounter = uint(fullmoveCounter)
+	}
+
+	return nil
+}
+
+// String returns the EPD in Extended Position Description, with every
+// operation terminated by a semicolon.
+//
+// Castling rights are written in Shredder-FEN if they were parsed in it, and
+// in X-FEN otherwise.
+//
+// Example:
+//
+//	String() // returns `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - bm e4; id "Start";`
+func (e EPD) String() string {
+	var sb strings.Builder
+
+	fen := e.Position.Fen()
+	if e.isShredder {
+		fen = e.Position.ShredderFen()
+	}
+
+	fields := strings.Fields(fen)
+	if e.hasClocks {
+		sb.WriteString(strings.Join(fields, " "))
+	} else {
+		sb.WriteString(strings.Join(fields[:4], " "))
+	}
+
+	for _, operation := range e.Operations {
+		sb.WriteString(" " + operation.Opcode)
+		for _, operand := range operation.Operands {
+			if epdStringOpcodes[operation.Opcode] || operand == "" || strings.ContainsAny(operand, " \t;\"") {
+				sb.WriteString(" " + writeEPDString(operand))
+			} else {
+				sb.WriteString(" " + operand)
+			}
+		}
+		sb.WriteString(";")
+	}
+
+	return sb.String()
+}
+
+// Operation returns the first operation with the passed opcode, and
+// whether it exists.
+func (e EPD) Operation(opcode string) (EPDOperation, bool) {
+	for _, operation := range e.Operations {
+		if operation.Opcode == opcode {
+			return operation, true
+		}
+	}
+	return EPDOperation{}, false
+}
+
+// SetOperation sets the operands of the operation with the passed opcode,
+// adding the operation at the end if it does not exist yet.
+//
+// Setting "hmvc" or "fmvn" also sets the position's halfmove clock or
+// fullmove counter.
+//
+// If the opcode or the clocks are invalid, it will return the error.
+//
+// Examples:
+//
+//	SetOperation("id", "BK.01")
+//	SetOperation("acd", "12")
+//	SetOperation("bm", "Nf3", "e4")
+func (e *EPD) SetOperation(opcode string, operands ...string) error {
+	if !isEPDOpcode(opcode) {
+		return fmt.Errorf("The opcode \"%s\" is not valid.", opcode)
+	}
+
+	previous := e.Operations
+	e.Operations = make([]EPDOperation, 0, len(previous)+1)
+
+	isSet := false
+	for _, operation := range previous {
+		if operation.Opcode != opcode {
+			e.Operations = append(e.Operations, operation)
+		} else if !isSet {
+			e.Operations = append(e.Operations, EPDOperation{Opcode: opcode, Operands: operands})
+			isSet = true
+		}
+	}
+	if !isSet {
+		e.Operations = append(e.Operations, EPDOperation{Opcode: opcode, Operands: operands})
+	}
+
+	if err := e.applyClocks(); err != nil {
+		e.Operations = previous
+		return err
+	}
+	return nil
+}
+
+// RemoveOperation removes the operations with the passed opcode.
+func (e *EPD) RemoveOperation(opcode string) {
+	operations := make([]EPDOperation, 0, len(e.Operations))
+	for _, operation := range e.Operations {
+		if operation.Opcode != opcode {
+			operations = append(operations, operation)
+		}
+	}
+	e.Operations = operations
+}
+
+// Operand returns the first operand of the operation with the passed opcode.
+//
+// If there is no such operation or it has no operands, it will return an
+// empty string.
+//
+// Examples:
+//
+//	Operand("id") // returns "BK.01"
+//	Operand("c0") // returns "Comment"
+func (e EPD) Operand(opcode string) string {
+	if operation, ok := e.Operation(opcode); ok && len(operation.Operands) > 0 {
+		return operation.Operands[0]
+	}
+	return ""
+}
+
+// IntOperand returns the first operand of the operation with the passed
+// opcode, as an integer. It is useful for opcodes such as "acd", "ce",
+// "dm", "hmvc" or "fmvn".
+//
+// If there is no such operation or its operand is not an integer, it will
+// return 0 and the error.
+func (e EPD) IntOperand(opcode string) (int, error) {
+	operation, ok := e.Operation(opcode)
+	if !ok || len(operation.Operands) == 0 {
+		return 0, fmt.Errorf("The EPD has no %s operation.", opcode)
+	}
+
+	value, err := strconv.Atoi(operation.Operands[0])
+	if err != nil {
+		return 0, fmt.Errorf("The %s operation does not have an integer operand.", opcode)
+	}
+	return value, nil
+}
+
+// PerftNodes returns the node counts of the perft operations ("D1", "D2",
+// ...), keyed by depth.
+//
+// If any node count is not a number, it will return the error.
+func (e EPD) PerftNodes() (map[int]uint64, error) {
+	nodes := make(map[int]uint64)
+
+	for _, operation := range e.Operations {
+		depth, err := strconv.Atoi(strings.TrimPrefix(operation.Opcode, "D"))
+		if !strings.HasPrefix(operation.Opcode, "D") || err != nil || depth < 1 {
+			continue
+		}
+
+		if len(operation.Operands) == 0 {
+			return nil, fmt.Errorf("The %s operation has no node count.", operation.Opcode)
+		}
+		if nodes[depth], err = strconv.ParseUint(operation.Operands[0], 10, 64); err != nil {
+			return nil, fmt.Errorf("The %s operation does not have a valid node count.", operation.Opcode)
+		}
+	}
+
+	return nodes, nil
+}
+
+// Movements returns the operands of the operation with the passed opcode,
+// as movements of the position. Operands are read in Standard Algebraic
+// Notation, or in Pure algebraic notation.
+//
+// The movements of "pv" are made one after another, as a line starting in
+// the position.
+//
+// If there is no such operation, it will return an empty list. If any
+// movement is invalid or illegal, it will return an empty list and the error.
+//
+// Examples:
+//
+//	Movements("bm") // returns [Movement{...}] for "bm Rxf4;"
+//	Movements("pv") // returns [Movement{...}, Movement{...}] for "pv e4 e5;"
+func (e EPD) Movements(opcode string) ([]Movement, error) {
+	operation, ok := e.Operation(opcode)
+	if !ok {
+		return []Movement{}, nil
+	}
+
+	game := newGameFromPosition(e.Position)
+	movements := make([]Movement, 0, len(operation.Operands))
+	for _, operand := range operation.Operands {
+		movement, err := game.parseSAN(operand)
+		if err != nil {
+			movement, err = game.parseEPDAlgebraic(operand, err)
+			if err != nil {
+				return []Movement{}, fmt.Errorf("The %s operation has an invalid movement \"%s\": %w", opcode, operand, err)
+			}
+		}
+
+		movements = append(movements, movement)
+		if epdSequentialOpcodes[opcode] {
+			game.forceMovement(movement, true)
+		}
+	}
+
+	return movements, nil
+}
+
+// parseEPDAlgebraic returns the legal movement written in Pure algebraic
+// notation, or the SAN parsing error if there is none.
+func (g *Game) parseEPDAlgebraic(algebraic string, sanErr error) (Movement, error) {
+	for _, legalMovement := range g.computedLegalMovements {
+		if legalMovement.Algebraic() == algebraic {
+			return legalMovement, nil
+		}
+	}
+	return Movement{}, sanErr
+}
+
+// SetMovements sets the operands of the operation with the passed opcode to
+// the movements, in Standard Algebraic Notation. The movements of "pv" must
+// be a line starting in the position.
+//
+// If any movement is not legal, it will return the error.
+//
+// Example:
+//
+//	SetMovements("bm", movement)
+func (e *EPD) SetMovements(opcode string, movements ...Movement) error {
+	game := newGameFromPosition(e.Position)
+	operands := make([]string, 0, len(movements))
+	for _, movement := range movements {
+		san, err := game.MovementSAN(movement)
+		if err != nil {
+			return err
+		}
+
+		operands = append(operands, san)
+		if epdSequentialOpcodes[opcode] {
+			game.forceMovement(movement, true)
+		}
+	}
+
+	return e.SetOperation(opcode, operands...)
+}
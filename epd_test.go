@@ -0,0 +1,142 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestParseEPD(t *testing.T) {
+	epd, err := ParseEPD(`r1bqk1r1/1p1p1n2/p1n2pN1/2p1b2Q/2P1Pp2/1PN5/PB4PP/R4RK1 w q - bm Rxf4; am Qh6 Nxe5; id "ERET 001 - Relief"; c0 "Tricky; isn't it"; acd 24; ce +35; pv Rxf4 Bxf4; hmvc 3; fmvn 18;`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if epd.Position.Fen() != "r1bqk1r1/1p1p1n2/p1n2pN1/2p1b2Q/2P1Pp2/1PN5/PB4PP/R4RK1 w q - 3 18" {
+		t.Errorf("Unexpected position %q", epd.Position.Fen())
+	}
+	if len(epd.Operations) != 9 || epd.Operand("id") != "ERET 001 - Relief" || epd.Operand("c0") != "Tricky; isn't it" {
+		t.Errorf("Unexpected operations %+v", epd.Operations)
+	}
+	if depth, err := epd.IntOperand("acd"); err != nil || depth != 24 {
+		t.Errorf("Expected an analysis depth of 24, got %d (%v)", depth, err)
+	}
+	if score, err := epd.IntOperand("ce"); err != nil || score != 35 {
+		t.Errorf("Expected an evaluation of 35, got %d (%v)", score, err)
+	}
+
+	if bm, err := epd.Movements("bm"); err != nil || len(bm) != 1 || bm[0].Algebraic() != "f1f4" {
+		t.Errorf("Expected the best movement f1f4, got %v (%v)", bm, err)
+	}
+	if am, err := epd.Movements("am"); err != nil || len(am) != 2 || am[0].Algebraic() != "h5h6" || am[1].Algebraic() != "g6e5" {
+		t.Errorf("Expected the movements to avoid h5h6 and g6e5, got %v (%v)", am, err)
+	}
+	if pv, err := epd.Movements("pv"); err != nil || len(pv) != 2 || pv[1].Algebraic() != "e5f4" {
+		t.Errorf("Expected the principal variation f1f4 e5f4, got %v (%v)", pv, err)
+	}
+
+	// Writing and parsing again must not lose anything
+	written := epd.String()
+	expected := `r1bqk1r1/1p1p1n2/p1n2pN1/2p1b2Q/2P1Pp2/1PN5/PB4PP/R4RK1 w q - bm Rxf4; am Qh6 Nxe5; id "ERET 001 - Relief"; c0 "Tricky; isn't it"; acd 24; ce +35; pv Rxf4 Bxf4; hmvc 3; fmvn 18;`
+	if written != expected {
+		t.Errorf("Expected %q, got %q", expected, written)
+	}
+	if reparsed, err := ParseEPD(written); err != nil || reparsed.String() != written {
+		t.Errorf("Expected %q to round trip, got %q (%v)", written, reparsed.String(), err)
+	}
+}
+
+func TestParseEPDPerft(t *testing.T) {
+	epd, err := ParseEPD("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1 ;D1 26 ;D2 568")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if nodes, err := epd.PerftNodes(); err != nil || len(nodes) != 2 || nodes[1] != 26 || nodes[2] != 568 {
+		t.Errorf("Expected the perft node counts, got %v (%v)", nodes, err)
+	}
+	if written := epd.String(); written != "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1 D1 26; D2 568;" {
+		t.Errorf("Unexpected written EPD %q", written)
+	}
+
+	// Chess960 castling rights and movements in Pure algebraic notation
+	epd, err = ParseEPD("1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w GBgb - bm e1g1 e2e4;")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if bm, err := epd.Movements("bm"); err != nil || len(bm) != 2 || !bm[0].IsKingSideCastling() {
+		t.Errorf("Expected a castling best movement, got %v (%v)", bm, err)
+	}
+	if written := epd.String(); written != "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w GBgb - bm e1g1 e2e4;" {
+		t.Errorf("Expected the Shredder-FEN castling rights to be kept, got %q", written)
+	}
+
+	invalidEPDs := []string{
+		"8/8/8/8/8/8/8/8 w",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 1bm e4;",
+		`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "Unterminated;`,
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - hmvc x;",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - bm e4;",
+	}
+	for _, invalidEPD := range invalidEPDs {
+		if _, err := ParseEPD(invalidEPD); err == nil {
+			t.Errorf("Expected an error parsing %q", invalidEPD)
+		}
+	}
+}
+
+func TestEPDSetOperation(t *testing.T) {
+	epd, _ := ParseEPD(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "Start"; bm d4;`)
+
+	movements := epd.Position.LegalMovements()
+	var e4, nf3 Movement
+	for _, movement := range movements {
+		switch movement.Algebraic() {
+		case "e2e4":
+			e4 = movement
+		case "g1f3":
+			nf3 = movement
+		}
+	}
+
+	if err := epd.SetMovements("bm", e4, nf3); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := epd.SetMovements("pv", e4, nf3); err == nil {
+		t.Errorf("Expected an error with an illegal principal variation")
+	}
+	if err := epd.SetOperation("fmvn", "12"); err != nil || epd.Position.FullmoveCounter() != 12 {
+		t.Errorf("Expected the fullmove counter to be set, got %d (%v)", epd.Position.FullmoveCounter(), err)
+	}
+	if err := epd.SetOperation("hmvc", "-1"); err == nil {
+		t.Errorf("Expected an error with an invalid halfmove clock")
+	}
+	epd.SetOperation("c1", "")
+	epd.RemoveOperation("id")
+
+	if written := epd.String(); written != `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - bm e4 Nf3; fmvn 12; c1 "";` {
+		t.Errorf("Unexpected written EPD %q", written)
+	}
+}
+
+func TestEPDStringQuotes(t *testing.T) {
+	epd, err := ParseEPD(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "The \"Start\""; c0 "C:\\Suites\\";`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if epd.Operand("id") != `The "Start"` || epd.Operand("c0") != `C:\Suites\` {
+		t.Errorf("Unexpected operations %+v", epd.Operations)
+	}
+
+	epd.SetOperation("c1", `Say "hi"`)
+	epd.SetOperation("bm", `e4"`)
+
+	written := epd.String()
+	reparsed, err := ParseEPD(written)
+	if err != nil {
+		t.Fatalf("Expected %q to be parsed again, got %s", written, err)
+	}
+	for _, opcode := range []string{"id", "c0", "c1", "bm"} {
+		if reparsed.Operand(opcode) != epd.Operand(opcode) {
+			t.Errorf("Expected the %s operand %q to round trip, got %q", opcode, epd.Operand(opcode), reparsed.Operand(opcode))
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Package epdsuite runs EPD test suites, such as WAC (Win at Chess) or STS
+// (Strategic Test Suite), against a search engine, to measure its strength.
+//
+// Each position is searched with a uci.Searcher, and the found movement is
+// scored against the position's "bm" (best movements) and "am" (movements to
+// avoid) operations, and against the STS weighted movements, if any.
+package epdsuite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/uci"
+)
+
+// Options represents the limits of the search of each position. Zero values
+// mean no limit, but at least one limit must be set.
+type Options struct {
+	Depth    int
+	Nodes    uint64
+	MoveTime time.Duration
+
+	// OnResult, if set, is called after each position is searched.
+	OnResult func(Result)
+}
+
+// Result represents the result of searching a position of the suite.
+type Result struct {
+	EPD chess.EPD
+	ID  string // The "id" operation, or the position's number in the suite if it has none
+
+	Movement chess.Movement // The movement found by the searcher
+	SAN      string         // The movement found, in Standard Algebraic Notation
+	Info     uci.Info       // The last info with a principal variation sent by the searcher
+	Time     time.Duration
+
+	// IsScored reports whether the position has "bm" or "am" operations,
+	// and IsSolved whether the movement found is one of the best movements
+	// and none of the movements to avoid.
+	IsScored bool
+	IsSolved bool
+
+	// Points and MaxPoints are the STS weighted score of the movement found,
+	// and the best possible one. They are 0 if the position has no weights.
+	Points    int
+	MaxPoints int
+
+	Err error // Set if the position could not be searched
+}
+
+// Report represents the results of a suite.
+type Report struct {
+	Results []Result
+
+	Solved int // Amount of solved positions
+	Scored int // Amount of positions with "bm" or "am" operations
+
+	Points    int // Sum of the STS weighted scores
+	MaxPoints int // Sum of the best possible STS weighted scores
+
+	Errors int // Amount of positions that could not be searched
+	Nodes  uint64
+	Time   time.Duration
+}
+
+// LoadFile reads the EPD suite of the file at the passed path.
+//
+// If any line is not a valid EPD, it will return the error, with its line number.
+func LoadFile(path string) ([]chess.EPD, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// Load reads an EPD suite, one EPD per line. Empty lines and lines
+// starting with "#" are skipped.
+//
+// If any line is not a valid EPD, it will return the error, with its line number.
+func Load(r io.Reader) ([]chess.EPD, error) {
+	epds := make([]chess.EPD, 0)
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		epd, err := chess.ParseEPD(line)
+		if err != nil {
+			return nil, fmt.Errorf("The EPD of line %d is not valid: %w", lineNumber, err)
+		}
+
+		epds = append(epds, epd)
+	}
+
+	return epds, scanner.Err()
+}
+
+// Run searches every position of the suite with the searcher, and returns
+// the report of the results.
+//
+// Positions that can not be searched are reported as errors in their
+// results, and the suite continues. If the context is done, it will return
+// the report of the positions searched until then, and the context's error.
+// The position being searched when the context is done is not reported.
+//
+// Example:
+//
+//	epds, _ := epdsuite.LoadFile("wac.epd")
+//	report, _ := epdsuite.Run(ctx, engine.NewUCISearcher(), epds, epdsuite.Options{MoveTime: time.Second})
+//	fmt.Print(report)
+func Run(ctx context.Context, searcher uci.Searcher, epds []chess.EPD, options Options) (Report, error) {
+	report := Report{
+		Results: make([]Result, 0, len(epds)),
+	}
+
+	params := uci.GoParams{
+		Depth:    options.Depth,
+		Nodes:    options.Nodes,
+		MoveTime: options.MoveTime,
+	}
+	if params.Depth == 0 && params.Nodes == 0 && params.MoveTime == 0 {
+		return report, errors.New("The suite must have a depth, nodes or time limit.")
+	}
+
+	for i, epd := range epds {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		result := runPosition(ctx, searcher, epd, params)
+		if err := ctx.Err(); err != nil {
+			// The search was interrupted, so its movement is not scored
+			return report, err
+		}
+		if result.ID == "" {
+			result.ID = strconv.Itoa(i + 1)
+		}
+		report.add(result)
+
+		if options.OnResult != nil {
+			options.OnResult(result)
+		}
+	}
+
+	return report, nil
+}
+
+// runPosition searches a position, and scores the movement found.
+func runPosition(ctx context.Context, searcher uci.Searcher, epd chess.EPD, params uci.GoParams) Result {
+	result := Result{
+		EPD: epd,
+		ID:  epd.Operand("id"),
+	}
+
+	bestMovements, err := epd.Movements("bm")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	avoidMovements, err := epd.Movements("am")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	weights := stsWeights(epd)
+
+	newGame := chess.NewGame
+	if epd.Position.IsChess960() {
+		newGame = chess.NewChess960GameFromFen
+	}
+	game, err := newGame(epd.Position.Fen())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	searchResult, err := searcher.Search(ctx, &game, params, func(info uci.Info) {
+		if len(info.PV) > 0 {
+			result.Info = info
+		}
+	})
+	result.Time = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if result.Movement, err = searchResult.BestMovement(&game); err != nil {
+		result.Err = err
+		return result
+	}
+	result.SAN, _ = game.MovementSAN(result.Movement)
+
+	result.IsScored = len(bestMovements) > 0 || len(avoidMovements) > 0
+	result.IsSolved = result.IsScored &&
+		(len(bestMovements) == 0 || slices.Contains(bestMovements, result.Movement)) &&
+		!slices.Contains(avoidMovements, result.Movement)
+
+	for algebraic, points := range weights {
+		result.MaxPoints = max(result.MaxPoints, points)
+		if algebraic == result.Movement.Algebraic() {
+			result.Points = points
+		}
+	}
+
+	return result
+}
+
+// stsWeights returns the points of each weighted movement of the position,
+// keyed by their Pure algebraic notation, as written in STS suites:
+// movements in "c9" and their points in "c8", or "SAN=points" pairs in
+// "c0", such as "f5=10, Be5+=2, Bf2=3".
+//
+// If the position has no valid weights, it will return an empty map.
+func stsWeights(epd chess.EPD) map[string]int {
+	weights := make(map[string]int)
+
+	movements := strings.Fields(epd.Operand("c9"))
+	points := strings.Fields(epd.Operand("c8"))
+	if len(movements) > 0 && len(movements) == len(points) {
+		for i, movement := range movements {
+			value, err := strconv.Atoi(points[i])
+			if err != nil {
+				return make(map[string]int)
+			}
+			weights[movement] = value
+		}
+		return weights
+	}
+
+	for _, pair := range strings.Split(epd.Operand("c0"), ",") {
+		san, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return make(map[string]int)
+		}
+
+		movement, err := chess.ParseSAN(epd.Position, san)
+		points, atoiErr := strconv.Atoi(value)
+		if err != nil || atoiErr != nil {
+			return make(map[string]int)
+		}
+		weights[movement.Algebraic()] = points
+	}
+
+	return weights
+}
+
+func (r *Report) add(result Result) {
+	r.Results = append(r.Results, result)
+
+	if result.Err != nil {
+		r.Errors++
+		return
+	}
+
+	if result.IsScored {
+		r.Scored++
+	}
+	if result.IsSolved {
+		r.Solved++
+	}
+	r.Points += result.Points
+	r.MaxPoints += result.MaxPoints
+	r.Nodes += result.Info.Nodes
+	r.Time += result.Time
+}
+
+// String returns the report as text: one line per position, with the
+// movement found and the expected ones, followed by the aggregate results.
+//
+// Example:
+//
+//	WAC.001  Qg6      solved  bm Qg6                    depth 7    212ms
+//	WAC.002  Rc2      failed  bm Rb2                    depth 9    1s
+//	Solved 1/2 (50.0%), 1530472 nodes in 1.212s
+func (r Report) String() string {
+	var sb strings.Builder
+
+	idWidth := 2
+	for _, result := range r.Results {
+		idWidth = max(idWidth, len(result.ID))
+	}
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			fmt.Fprintf(&sb, "%-*s  error: %s\n", idWidth, result.ID, result.Err)
+			continue
+		}
+
+		status := "-"
+		if result.IsSolved {
+			status = "solved"
+		} else if result.IsScored {
+			status = "failed"
+		}
+
+		var expected []string
+		for _, opcode := range []string{"bm", "am"} {
+			if operation, ok := result.EPD.Operation(opcode); ok {
+				expected = append(expected, opcode+" "+strings.Join(operation.Operands, " "))
+			}
+		}
+		if result.MaxPoints > 0 {
+			expected = append(expected, fmt.Sprintf("points %d/%d", result.Points, result.MaxPoints))
+		}
+
+		fmt.Fprintf(&sb, "%-*s  %-7s  %-6s  %-24s  depth %-3d  %s\n", idWidth, result.ID, result.SAN, status, strings.Join(expected, ", "), result.Info.Depth, result.Time.Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(&sb, "Solved %d/%d (%.1f%%)", r.Solved, r.Scored, percentage(r.Solved, r.Scored))
+	if r.MaxPoints > 0 {
+		fmt.Fprintf(&sb, ", STS score %d/%d (%.1f%%)", r.Points, r.MaxPoints, percentage(r.Points, r.MaxPoints))
+	}
+	if r.Errors > 0 {
+		fmt.Fprintf(&sb, ", %d errors", r.Errors)
+	}
+	fmt.Fprintf(&sb, ", %d nodes in %s\n", r.Nodes, r.Time.Round(time.Millisecond))
+
+	return sb.String()
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
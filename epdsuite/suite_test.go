@@ -0,0 +1,102 @@
+package epdsuite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/keelus/chess"
+	"github.com/keelus/chess/engine"
+	"github.com/keelus/chess/uci"
+)
+
+const suite = `# Mates in one and STS-style weights
+6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - bm Rd8#; id "back rank";
+r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - am Qxf7+; id "fails";
+
+6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - c0 "Rd8+=10, Kf1=3, h3=1";
+6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - c8 "10 4"; c9 "d1e1 d1d8";
+`
+
+// cancellingSearcher cancels the suite during its first search, and then
+// returns the movement found until then.
+type cancellingSearcher struct {
+	uci.Searcher
+	cancel context.CancelFunc
+}
+
+func (s cancellingSearcher) Search(ctx context.Context, game *chess.Game, params uci.GoParams, onInfo func(uci.Info)) (uci.SearchResult, error) {
+	s.cancel()
+	return s.Searcher.Search(ctx, game, params, onInfo)
+}
+
+func TestRun(t *testing.T) {
+	epds, err := Load(strings.NewReader(suite))
+	if err != nil || len(epds) != 4 {
+		t.Fatalf("Expected 4 positions, got %d (%v)", len(epds), err)
+	}
+
+	var ids []string
+	report, err := Run(context.Background(), engine.NewUCISearcher(), epds, Options{
+		Depth: 3,
+		OnResult: func(result Result) {
+			ids = append(ids, result.ID)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if strings.Join(ids, ",") != "back rank,fails,3,4" {
+		t.Errorf("Unexpected results %v", ids)
+	}
+	if result := report.Results[0]; !result.IsSolved || result.SAN != "Rd8#" || result.Info.Depth == 0 {
+		t.Errorf("Expected the mate to be found, got %+v", result)
+	}
+	if result := report.Results[1]; result.IsSolved || !result.IsScored || result.SAN != "Qxf7#" {
+		t.Errorf("Expected the movement to avoid to fail, got %+v", result)
+	}
+	if result := report.Results[2]; result.IsScored || result.Points != 10 || result.MaxPoints != 10 {
+		t.Errorf("Expected 10/10 points, got %+v", result)
+	}
+	if result := report.Results[3]; result.Points != 4 || result.MaxPoints != 10 {
+		t.Errorf("Expected 4/10 points, got %+v", result)
+	}
+
+	if report.Solved != 1 || report.Scored != 2 || report.Points != 14 || report.MaxPoints != 20 || report.Errors != 0 || report.Nodes == 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if text := report.String(); !strings.Contains(text, "Solved 1/2 (50.0%), STS score 14/20 (70.0%)") || !strings.Contains(text, "back rank  Rd8#     solved  bm Rd8#") {
+		t.Errorf("Unexpected report text:\n%s", text)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := Load(strings.NewReader("8/8/8 w - - bm e4;")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error with the line number, got %v", err)
+	}
+
+	epds, _ := Load(strings.NewReader("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - bm Qh5;"))
+	if _, err := Run(context.Background(), engine.NewUCISearcher(), epds, Options{}); err == nil {
+		t.Errorf("Expected an error without limits")
+	}
+
+	report, err := Run(context.Background(), engine.NewUCISearcher(), epds, Options{Depth: 1})
+	if err != nil || report.Errors != 1 || report.Results[0].Err == nil {
+		t.Errorf("Expected the illegal best movement to be reported, got %+v (%v)", report, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report, err := Run(ctx, engine.NewUCISearcher(), epds, Options{Depth: 1}); err == nil || len(report.Results) != 0 {
+		t.Errorf("Expected the cancelled suite to end, got %+v (%v)", report, err)
+	}
+
+	// A search interrupted by the context must not be scored
+	ctx, cancel = context.WithCancel(context.Background())
+	epds, _ = Load(strings.NewReader(suite))
+	searcher := cancellingSearcher{Searcher: engine.NewUCISearcher(), cancel: cancel}
+	if report, err := Run(ctx, searcher, epds, Options{Depth: 1}); err == nil || len(report.Results) != 0 {
+		t.Errorf("Expected the interrupted position not to be reported, got %+v (%v)", report, err)
+	}
+}
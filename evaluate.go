@@ -0,0 +1,453 @@
+package chess
+
+// The game phase of a position with all its pieces. Each knight and bishop
+// counts 1, each rook 2 and each queen 4.
+const maxPhase = 24
+
+var phaseWeights = [KIND_AMOUNT + 1]int{
+	Kind_Queen:  4,
+	Kind_Rook:   2,
+	Kind_Bishop: 1,
+	Kind_Knight: 1,
+}
+
+// Material value of each piece kind, in centipawns, in the midgame and endgame.
+var materialValues = [KIND_AMOUNT + 1]taperedScore{
+	Kind_Queen:  {900, 940},
+	Kind_Rook:   {500, 530},
+	Kind_Bishop: {330, 320},
+	Kind_Knight: {320, 300},
+	Kind_Pawn:   {100, 120},
+}
+
+// Piece-square tables, from white's point of view, with the 8th rank first
+// (the same layout as the board). They are the tables of Tomasz Michniewski's
+// Simplified Evaluation Function, and are used in both phases, except for the
+// king, which uses kingEndgameTable in the endgame.
+var pieceSquareTables = [KIND_AMOUNT + 1][8][8]int{
+	Kind_Pawn: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	Kind_Knight: {
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	},
+	Kind_Bishop: {
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	},
+	Kind_Rook: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	},
+	Kind_Queen: {
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	},
+	Kind_King: {
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	},
+}
+
+var kingEndgameTable = [8][8]int{
+	{-50, -40, -30, -20, -20, -30, -40, -50},
+	{-30, -20, -10, 0, 0, -10, -20, -30},
+	{-30, -10, 20, 30, 30, 20, -10, -30},
+	{-30, -10, 30, 40, 40, 30, -10, -30},
+	{-30, -10, 30, 40, 40, 30, -10, -30},
+	{-30, -10, 20, 30, 30, 20, -10, -30},
+	{-30, -30, 0, 0, 0, 0, -30, -30},
+	{-50, -30, -30, -30, -30, -30, -30, -50},
+}
+
+// Mobility score of each piece kind, per reachable square above (or below)
+// the kind's usual amount of reachable squares.
+var mobilityWeights = [KIND_AMOUNT + 1]taperedScore{
+	Kind_Queen:  {1, 2},
+	Kind_Rook:   {2, 4},
+	Kind_Bishop: {5, 5},
+	Kind_Knight: {4, 4},
+}
+var mobilityBaselines = [KIND_AMOUNT + 1]int{
+	Kind_Queen:  13,
+	Kind_Rook:   7,
+	Kind_Bishop: 6,
+	Kind_Knight: 4,
+}
+
+// Pawn structure scores. Passed pawn bonuses are indexed by the pawn's row,
+// counted from its own side (0 is the 1st rank for white).
+var (
+	doubledPawnPenalty  = taperedScore{-10, -20} // For each extra pawn in a column
+	isolatedPawnPenalty = taperedScore{-10, -15}
+	passedPawnBonuses   = [8]taperedScore{{0, 0}, {5, 10}, {10, 15}, {15, 25}, {25, 45}, {45, 75}, {70, 120}, {0, 0}}
+)
+
+// King safety scores. They only apply to the midgame.
+var (
+	pawnShieldBonuses   = [3]int{0, 10, 5} // Indexed by the distance of the shielding pawn to the king's row
+	openColumnPenalty   = -15              // For each column next to the king without ally pawns
+	kingAttackerWeights = [KIND_AMOUNT + 1]int{
+		Kind_Queen:  5,
+		Kind_Rook:   3,
+		Kind_Bishop: 2,
+		Kind_Knight: 2,
+	}
+)
+
+// Masks of the squares in front of a pawn, in its column and the adjacent
+// ones, for each color and square index. A pawn is passed if there are no
+// opponent pawns in its mask.
+var passedPawnMasks [COLOR_AMOUNT + 1][64]bitboard
+
+// Masks of the squares of each column, and of its adjacent columns.
+var columnMasks [8]bitboard
+var adjacentColumnMasks [8]bitboard
+
+func init() {
+	for j := 0; j < 8; j++ {
+		columnMasks[j] = bitboard(0x0101010101010101) << j
+	}
+	for j := 0; j < 8; j++ {
+		if j > 0 {
+			adjacentColumnMasks[j] |= columnMasks[j-1]
+		}
+		if j < 7 {
+			adjacentColumnMasks[j] |= columnMasks[j+1]
+		}
+	}
+
+	for index := 0; index < 64; index++ {
+		i, j := index/8, index%8
+		span := columnMasks[j] | adjacentColumnMasks[j]
+
+		// Rows in front of white pawns are the ones with a lower I
+		passedPawnMasks[Color_White][index] = span & (bitboardOf(i*8) - 1)
+		passedPawnMasks[Color_Black][index] = span &^ (bitboardOf((i+1)*8) - 1)
+	}
+}
+
+// taperedScore represents a score in the midgame and in the endgame.
+type taperedScore struct {
+	mg, eg int
+}
+
+func (s *taperedScore) add(other taperedScore) {
+	s.mg += other.mg
+	s.eg += other.eg
+}
+
+func (s taperedScore) scaled(factor int) taperedScore {
+	return taperedScore{s.mg * factor, s.eg * factor}
+}
+
+// blend interpolates the score between its midgame and endgame values,
+// by the passed game phase.
+func (s taperedScore) blend(phase int) int {
+	return (s.mg*phase + s.eg*(maxPhase-phase)) / maxPhase
+}
+
+// EvaluationTerm represents the score of one of the terms of an evaluation,
+// in centipawns, from white's point of view.
+type EvaluationTerm struct {
+	Midgame int
+	Endgame int
+	Score   int // Midgame and Endgame blended by the game phase
+}
+
+// Evaluation represents the detailed static evaluation of a position, with
+// the score of each of its terms. All the scores are in centipawns, from
+// white's point of view.
+type Evaluation struct {
+	Material      EvaluationTerm
+	PieceSquares  EvaluationTerm
+	Mobility      EvaluationTerm // Squares reachable by knights, bishops, rooks and queens
+	PawnStructure EvaluationTerm // Doubled, isolated and passed pawns
+	KingSafety    EvaluationTerm // Pawn shield, open columns and attackers near the king
+
+	// Phase is the game phase, from 24 (all the pieces on the board) to 0
+	// (only kings and pawns), used to blend the midgame and endgame scores.
+	Phase int
+
+	Score int // The sum of the terms' scores
+}
+
+// Evaluate returns the static evaluation of the position, in centipawns,
+// from the point of view of the player to move. Positive scores mean that
+// the player to move is better.
+//
+// It doesn't search any movement, so it doesn't detect checkmates or
+// pending captures. Use EvaluateDetailed to get the score of each term.
+//
+// Examples:
+//
+//	game, _ := chess.NewGame("")
+//	chess.Evaluate(game.CurrentPosition()) // returns 0
+//
+//	game, _ = chess.NewGame("rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1")
+//	chess.Evaluate(game.CurrentPosition()) // returns a negative score, as black has no queen
+func Evaluate(position Position) int {
+	score := EvaluateDetailed(position).Score
+	if position.playerToMove == Color_Black {
+		return -score
+	}
+	return score
+}
+
+// MaterialValue returns the midgame material value of the piece kind used
+// by Evaluate, in centipawns. The king has no material value.
+//
+// Examples:
+//
+//	chess.MaterialValue(chess.Kind_Queen) // returns 900
+//	chess.MaterialValue(chess.Kind_King)  // returns 0
+func MaterialValue(kind Kind) int {
+	return materialValues[kind].mg
+}
+
+// EvaluateDetailed returns the static evaluation of the position, with the
+// score of each of its terms, from white's point of view. It's useful to
+// explain an evaluation to the user.
+//
+// Examples:
+//
+//	evaluation := chess.EvaluateDetailed(position)
+//	evaluation.Material.Score // returns 330, if white has an extra bishop
+//	evaluation.Score          // returns the sum of all the terms
+func EvaluateDetailed(position Position) Evaluation {
+	var material, pieceSquares, mobility, pawnStructure, kingSafety taperedScore
+
+	phase := 0
+	for color := Color_White; color <= Color_Black; color++ {
+		for kind := Kind_King; kind <= Kind_Pawn; kind++ {
+			phase += phaseWeights[kind] * position.pieceBitboards[color][kind].count()
+		}
+	}
+	phase = min(phase, maxPhase) // Promotions may add pieces
+
+	for color := Color_White; color <= Color_Black; color++ {
+		sign := 1
+		if color == Color_Black {
+			sign = -1
+		}
+
+		material.add(position.evaluateMaterial(color).scaled(sign))
+		pieceSquares.add(position.evaluatePieceSquares(color).scaled(sign))
+		mobility.add(position.evaluateMobility(color).scaled(sign))
+		pawnStructure.add(position.evaluatePawnStructure(color).scaled(sign))
+		kingSafety.add(position.evaluateKingSafety(color).scaled(sign))
+	}
+
+	evaluation := Evaluation{
+		Material:      newEvaluationTerm(material, phase),
+		PieceSquares:  newEvaluationTerm(pieceSquares, phase),
+		Mobility:      newEvaluationTerm(mobility, phase),
+		PawnStructure: newEvaluationTerm(pawnStructure, phase),
+		KingSafety:    newEvaluationTerm(kingSafety, phase),
+		Phase:         phase,
+	}
+	evaluation.Score = evaluation.Material.Score + evaluation.PieceSquares.Score + evaluation.Mobility.Score + evaluation.PawnStructure.Score + evaluation.KingSafety.Score
+
+	return evaluation
+}
+
+func newEvaluationTerm(score taperedScore, phase int) EvaluationTerm {
+	return EvaluationTerm{
+		Midgame: score.mg,
+		Endgame: score.eg,
+		Score:   score.blend(phase),
+	}
+}
+
+func (p *Position) evaluateMaterial(color Color) taperedScore {
+	var score taperedScore
+	for kind := Kind_Queen; kind <= Kind_Pawn; kind++ {
+		score.add(materialValues[kind].scaled(p.pieceBitboards[color][kind].count()))
+	}
+	return score
+}
+
+func (p *Position) evaluatePieceSquares(color Color) taperedScore {
+	var score taperedScore
+	for kind := Kind_King; kind <= Kind_Pawn; kind++ {
+		for pieces := p.pieceBitboards[color][kind]; pieces != 0; pieces &= pieces - 1 {
+			index := pieces.first()
+			i, j := index/8, index%8
+			if color == Color_Black {
+				i = 7 - i // Tables are from white's point of view
+			}
+
+			endgame := pieceSquareTables[kind][i][j]
+			if kind == Kind_King {
+				endgame = kingEndgameTable[i][j]
+			}
+			score.add(taperedScore{pieceSquareTables[kind][i][j], endgame})
+		}
+	}
+	return score
+}
+
+// pawnAttacked returns the squares attacked by the pawns of the passed color.
+func (p *Position) pawnAttacked(color Color) bitboard {
+	var attacked bitboard
+	for pawns := p.pieceBitboards[color][Kind_Pawn]; pawns != 0; pawns &= pawns - 1 {
+		attacked |= pawnAttacks[color][pawns.first()]
+	}
+	return attacked
+}
+
+// pieceAttacks returns the squares attacked by a knight, bishop, rook or
+// queen at the passed square index.
+func pieceAttacks(kind Kind, index int, occupied bitboard) bitboard {
+	switch kind {
+	case Kind_Knight:
+		return knightAttacks[index]
+	case Kind_Bishop:
+		return bishopAttacks(index, occupied)
+	case Kind_Rook:
+		return rookAttacks(index, occupied)
+	case Kind_Queen:
+		return rookAttacks(index, occupied) | bishopAttacks(index, occupied)
+	}
+	return 0
+}
+
+// evaluateMobility scores the squares reachable by the color's pieces, not
+// occupied by ally pieces nor attacked by opponent pawns.
+func (p *Position) evaluateMobility(color Color) taperedScore {
+	var score taperedScore
+
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	available := ^p.colorBitboards[color] &^ p.pawnAttacked(color.Opposite())
+
+	for kind := Kind_Queen; kind <= Kind_Knight; kind++ {
+		for pieces := p.pieceBitboards[color][kind]; pieces != 0; pieces &= pieces - 1 {
+			reachable := (pieceAttacks(kind, pieces.first(), occupied) & available).count()
+			score.add(mobilityWeights[kind].scaled(reachable - mobilityBaselines[kind]))
+		}
+	}
+
+	return score
+}
+
+func (p *Position) evaluatePawnStructure(color Color) taperedScore {
+	var score taperedScore
+
+	pawns := p.pieceBitboards[color][Kind_Pawn]
+	opponentPawns := p.pieceBitboards[color.Opposite()][Kind_Pawn]
+
+	for j := 0; j < 8; j++ {
+		if amount := (pawns & columnMasks[j]).count(); amount > 1 {
+			score.add(doubledPawnPenalty.scaled(amount - 1))
+		}
+	}
+
+	for remaining := pawns; remaining != 0; remaining &= remaining - 1 {
+		index := remaining.first()
+		i, j := index/8, index%8
+
+		if pawns&adjacentColumnMasks[j] == 0 {
+			score.add(isolatedPawnPenalty)
+		}
+
+		if opponentPawns&passedPawnMasks[color][index] == 0 {
+			row := 7 - i
+			if color == Color_Black {
+				row = i
+			}
+			score.add(passedPawnBonuses[row])
+		}
+	}
+
+	return score
+}
+
+// evaluateKingSafety scores the ally pawns shielding the color's king, the
+// columns next to it without ally pawns, and the opponent pieces attacking
+// the squares around it.
+func (p *Position) evaluateKingSafety(color Color) taperedScore {
+	kings := p.pieceBitboards[color][Kind_King]
+	if kings == 0 {
+		return taperedScore{}
+	}
+
+	kingIndex := kings.first()
+	kingI, kingJ := kingIndex/8, kingIndex%8
+	pawns := p.pieceBitboards[color][Kind_Pawn]
+	direction := int(pawnMoveRowDirections[color])
+
+	score := 0
+	for j := max(kingJ-1, 0); j <= min(kingJ+1, 7); j++ {
+		if pawns&columnMasks[j] == 0 {
+			score += openColumnPenalty
+			continue
+		}
+
+		for distance := 1; distance < len(pawnShieldBonuses); distance++ {
+			i := kingI + direction*distance
+			if i >= 0 && i < 8 && pawns.has(i*8+j) {
+				score += pawnShieldBonuses[distance]
+				break
+			}
+		}
+	}
+
+	// Attacks to the king zone, weighted by the attacking piece. A single
+	// attacker is not considered dangerous.
+	zone := kingAttacks[kingIndex] | bitboardOf(kingIndex)
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	opponent := color.Opposite()
+
+	attackers, attackWeight := 0, 0
+	for kind := Kind_Queen; kind <= Kind_Knight; kind++ {
+		for pieces := p.pieceBitboards[opponent][kind]; pieces != 0; pieces &= pieces - 1 {
+			if pieceAttacks(kind, pieces.first(), occupied)&zone != 0 {
+				attackers++
+				attackWeight += kingAttackerWeights[kind]
+			}
+		}
+	}
+	if attackers > 1 {
+		score -= attackWeight * attackWeight / 4
+	}
+
+	return taperedScore{score, 0}
+}
@@ -0,0 +1,109 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// mirrorFen returns the fen with the board flipped vertically and the colors
+// swapped, without castling rights nor en passant.
+func mirrorFen(fen string) string {
+	fields := strings.Fields(fen)
+
+	rows := strings.Split(fields[0], "/")
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	board := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, strings.Join(rows, "/"))
+
+	turn := "w"
+	if fields[1] == "w" {
+		turn = "b"
+	}
+
+	return board + " " + turn + " - - 0 1"
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w - - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w - - 1 8",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+
+	for _, fen := range fens {
+		game, _ := NewGame(fen)
+		mirrored, _ := NewGame(mirrorFen(fen))
+
+		if a, b := Evaluate(game.CurrentPosition()), Evaluate(mirrored.CurrentPosition()); a != b {
+			t.Errorf("Expected %q and its mirror to have the same evaluation, got %d and %d", fen, a, b)
+		}
+	}
+}
+
+func TestEvaluateDetailed(t *testing.T) {
+	game, _ := NewGame("")
+	evaluation := EvaluateDetailed(game.CurrentPosition())
+	if evaluation.Score != 0 || evaluation.Phase != maxPhase {
+		t.Errorf("Expected the starting position to score 0 with phase %d, got %+v", maxPhase, evaluation)
+	}
+
+	// Black is missing its queen
+	game, _ = NewGame("rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1")
+	evaluation = EvaluateDetailed(game.CurrentPosition())
+	if evaluation.Material.Midgame != 900 || evaluation.Material.Endgame != 940 || evaluation.Phase != maxPhase-4 {
+		t.Errorf("Expected a material score of 900/940 with phase %d, got %+v", maxPhase-4, evaluation)
+	}
+	if evaluation.Material.Score != (900*20+940*4)/maxPhase {
+		t.Errorf("Expected the material score to be blended by the phase, got %d", evaluation.Material.Score)
+	}
+	if score := Evaluate(game.CurrentPosition()); score != -evaluation.Score {
+		t.Errorf("Expected the evaluation from black's point of view to be %d, got %d", -evaluation.Score, score)
+	}
+
+	terms := evaluation.Material.Score + evaluation.PieceSquares.Score + evaluation.Mobility.Score + evaluation.PawnStructure.Score + evaluation.KingSafety.Score
+	if terms != evaluation.Score {
+		t.Errorf("Expected the terms to sum %d, got %d", evaluation.Score, terms)
+	}
+
+	// Only kings and pawns: the endgame scores are used
+	game, _ = NewGame("4k3/8/8/3P4/8/8/8/4K3 w - - 0 1")
+	evaluation = EvaluateDetailed(game.CurrentPosition())
+	if evaluation.Phase != 0 || evaluation.PawnStructure.Score != evaluation.PawnStructure.Endgame {
+		t.Errorf("Expected an endgame evaluation, got %+v", evaluation)
+	}
+	if evaluation.PawnStructure.Endgame != isolatedPawnPenalty.eg+passedPawnBonuses[4].eg {
+		t.Errorf("Expected an isolated passed pawn, got a pawn structure of %+v", evaluation.PawnStructure)
+	}
+}
+
+func TestEvaluateKingSafety(t *testing.T) {
+	// The same castled king, with and without its pawn shield
+	sheltered, _ := NewGame("4k3/8/8/8/8/8/5PPP/6K1 w - - 0 1")
+	exposed, _ := NewGame("4k3/8/8/8/8/5PPP/8/6K1 w - - 0 1")
+
+	if a, b := EvaluateDetailed(sheltered.CurrentPosition()).KingSafety.Midgame, EvaluateDetailed(exposed.CurrentPosition()).KingSafety.Midgame; a <= b {
+		t.Errorf("Expected the sheltered king to be safer, got %d and %d", a, b)
+	}
+
+	// Queen and rook attacking the king zone, and the same pieces away from it
+	attacked, _ := NewGame("6k1/8/8/8/8/8/5PPP/3q1rK1 w - - 0 1")
+	distant, _ := NewGame("qr4k1/8/8/8/8/8/5PPP/6K1 w - - 0 1")
+
+	if a, b := EvaluateDetailed(attacked.CurrentPosition()).KingSafety.Midgame, EvaluateDetailed(distant.CurrentPosition()).KingSafety.Midgame; a >= b {
+		t.Errorf("Expected the attacked king to be penalized, got %d and %d", a, b)
+	}
+}
+
+func TestMaterialValue(t *testing.T) {
+	if MaterialValue(Kind_Queen) != 900 || MaterialValue(Kind_Pawn) != 100 || MaterialValue(Kind_King) != 0 {
+		t.Errorf("Unexpected material values %d, %d and %d", MaterialValue(Kind_Queen), MaterialValue(Kind_Pawn), MaterialValue(Kind_King))
+	}
+}
@@ -10,10 +10,7 @@ type fenData struct {
 	placementData [8]string
 	activeColor   Color
 
-	whiteCanKingSideCastling  bool
-	whiteCanQueenSideCastling bool
-	blackCanKingSideCastling  bool
-	blackCanQueenSideCastling bool
+	castling string // Standard, X-FEN or Shredder-FEN castling rights
 
 	enPassantSq *Square
 
@@ -44,25 +41,8 @@ func parseFen(fen string) (fenData, error) {
 		return fenData{}, errors.New("The provided FEN does not have a valid active color.")
 	}
 
-	whiteCanKingSideCastling := false
-	whiteCanQueenSideCastling := false
-	blackCanKingSideCastling := false
-	blackCanQueenSideCastling := false
-
-	if parts[2] != "-" {
-		if strings.Contains(parts[2], "K") {
-			whiteCanKingSideCastling = true
-		}
-		if strings.Contains(parts[2], "Q") {
-			whiteCanQueenSideCastling = true
-		}
-
-		if strings.Contains(parts[2], "k") {
-			blackCanKingSideCastling = true
-		}
-		if strings.Contains(parts[2], "q") {
-			blackCanQueenSideCastling = true
-		}
+	if parts[2] != "-" && strings.Trim(parts[2], "KQkqABCDEFGHabcdefgh") != "" {
+		return fenData{}, errors.New("The provided FEN does not have valid castling rights. They must be in standard, X-FEN or Shredder-FEN notation, or empty: -.")
 	}
 
 	var enPassant *Square = nil
@@ -92,9 +72,6 @@ func parseFen(fen string) (fenData, error) {
 		halfmoveClock:  uint8(halfmoveClock),
 		fulmoveCounter: uint(fullmoveCounter),
 
-		whiteCanKingSideCastling:  whiteCanKingSideCastling,
-		whiteCanQueenSideCastling: whiteCanQueenSideCastling,
-		blackCanKingSideCastling:  blackCanKingSideCastling,
-		blackCanQueenSideCastling: blackCanQueenSideCastling,
+		castling: parts[2],
 	}, nil
 }
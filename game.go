@@ -14,8 +14,19 @@ type Game struct {
 
 	outcome Outcome // Not used by Perft.
 
-	positionMap     map[string]uint8 // Not used by Perft (ignores Threefold).
+	positionMap     map[uint64]uint8 // Occurrences of each repetitionKey(). Not used by Perft (ignores Threefold).
 	movementHistory []Movement       // Not used by Perft.
+	outcomeHistory  []Outcome        // Outcome before each movement. Not used by Perft.
+	redoMovements   []Movement       // Undone movements, the most recent one last.
+
+	tags map[string]string // PGN tag pairs.
+
+	drawMode      DrawMode
+	drawOfferedBy Color // Color_None if there is no pending draw offer.
+
+	clock         *Clock         // Nil for non-timed games.
+	clockHistory  []Clock        // Clock before each movement. Not used by Perft.
+	movementTimes []MovementTime // Not used by Perft.
 }
 
 // NewGame creates and returns an new Game instance, based on the provided FEN string.
@@ -34,19 +45,28 @@ func NewGame(fen string) (Game, error) {
 		return Game{}, err
 	}
 
+	return newGameFromPosition(startingPosition), nil
+}
+
+func newGameFromPosition(startingPosition Position) Game {
 	newGame := Game{
 		positions:            make([]Position, 0),
 		currentPosition:      startingPosition,
 		currentPositionIndex: 0,
 
-		positionMap: make(map[string]uint8),
+		positionMap: make(map[uint64]uint8),
 
 		outcome: Outcome_None,
+
+		tags: make(map[string]string),
+
+		drawOfferedBy: Color_None,
 	}
 
 	newGame.computeLegalMovements()
+	newGame.positionMap[newGame.repetitionKey()] = 1
 
-	return newGame, nil
+	return newGame
 }
 
 // Turn returns the player/side to move's color of the current
@@ -190,13 +210,52 @@ func (g *Game) MakeMovement(movement Movement) error {
 // MakeMovementAlgebraic tries to make the given movement in Pure
 // algebraic notation.
 //
-// If the movement is invalid, it will return an error.
+// If the movement is invalid, a player ran out of time, resigned or agreed
+// to a draw, it will return an error.
 func (g *Game) MakeMovementAlgebraic(algebraicMovement string) error {
+	switch g.outcome.Method() {
+	case Method_Resignation, Method_Agreement:
+		return errors.New("The game has already ended.")
+	}
+
+	if g.CheckTimeout() || g.outcome.Method() == Method_Timeout {
+		return errors.New("The player to move ran out of time.")
+	}
+
 	for _, legalMovement := range g.computedLegalMovements {
 		if legalMovement.Algebraic() == algebraicMovement {
+			var movementTime MovementTime
+			if g.clock != nil && g.clock.running != Color_None {
+				g.clockHistory = append(g.clockHistory, *g.clock)
+
+				var inTime bool
+				if movementTime, inTime = g.clock.press(); !inTime {
+					g.clockHistory = g.clockHistory[:len(g.clockHistory)-1]
+					g.CheckTimeout()
+					return errors.New("The player to move ran out of time.")
+				}
+			} else {
+				g.clockHistory = append(g.clockHistory, Clock{})
+			}
+
+			// A pending draw offer expires once the opponent moves
+			if g.drawOfferedBy == legalMovement.movingPiece.Color.Opposite() {
+				g.drawOfferedBy = Color_None
+			}
+
+			g.outcomeHistory = append(g.outcomeHistory, g.outcome)
 			g.movementHistory = append(g.movementHistory, legalMovement)
+			g.movementTimes = append(g.movementTimes, movementTime)
 			g.currentPositionIndex++
 			g.forceMovement(legalMovement, true)
+
+			// Keep the redo stack only if the undone movement is the one being played
+			if len(g.redoMovements) > 0 && g.redoMovements[len(g.redoMovements)-1].Algebraic() == algebraicMovement {
+				g.redoMovements = g.redoMovements[:len(g.redoMovements)-1]
+			} else {
+				g.redoMovements = nil
+			}
+
 			return nil
 		}
 	}
@@ -204,6 +263,79 @@ func (g *Game) MakeMovementAlgebraic(algebraicMovement string) error {
 	return errors.New("That movement is not allowed or is invalid.")
 }
 
+// UndoMovement takes back the most recent movement of the game, restoring
+// the previous position, movement history, repetition counts and outcome.
+// Any pending draw offer is discarded.
+//
+// In timed games, the player's clock is restored to the time it had
+// before the movement, while the opponent keeps the time it used since.
+//
+// The undone movement can be made again via RedoMovement(), until a
+// different movement is made.
+//
+// If there are no movements to undo, it will return an error.
+func (g *Game) UndoMovement() error {
+	if len(g.movementHistory) == 0 {
+		return errors.New("There are no movements to undo.")
+	}
+
+	lastIndex := len(g.movementHistory) - 1
+	movement := g.movementHistory[lastIndex]
+
+	repetitionKey := g.repetitionKey()
+	g.positionMap[repetitionKey]--
+	if g.positionMap[repetitionKey] == 0 {
+		delete(g.positionMap, repetitionKey)
+	}
+
+	g.currentPosition = g.positions[len(g.positions)-1]
+	g.positions = g.positions[:len(g.positions)-1]
+	g.currentPositionIndex--
+	g.movementHistory = g.movementHistory[:lastIndex]
+	g.outcome = g.outcomeHistory[lastIndex]
+	g.outcomeHistory = g.outcomeHistory[:lastIndex]
+	g.movementTimes = g.movementTimes[:lastIndex]
+	if g.clock != nil && g.clockHistory[lastIndex].running != Color_None {
+		// Restore the player's time before the movement, and resume its clock
+		// from now. The opponent keeps being charged for the time it used.
+		color, previous := movement.movingPiece.Color, g.clockHistory[lastIndex]
+
+		g.clock.Stop()
+		g.clock.remaining[color] = previous.remaining[color]
+		g.clock.stage[color] = previous.stage[color]
+		g.clock.stageMovements[color] = previous.stageMovements[color]
+		g.clock.Start(color)
+	}
+	g.clockHistory = g.clockHistory[:lastIndex]
+	g.redoMovements = append(g.redoMovements, movement)
+	g.drawOfferedBy = Color_None
+
+	g.computeLegalMovements()
+
+	return nil
+}
+
+// RedoMovement makes again the most recently undone movement.
+//
+// If there are no movements to redo, it will return an error.
+func (g *Game) RedoMovement() error {
+	if len(g.redoMovements) == 0 {
+		return errors.New("There are no movements to redo.")
+	}
+
+	return g.MakeMovement(g.redoMovements[len(g.redoMovements)-1])
+}
+
+// CanUndoMovement reports whether there is any movement to undo.
+func (g *Game) CanUndoMovement() bool {
+	return len(g.movementHistory) > 0
+}
+
+// CanRedoMovement reports whether there is any undone movement to redo.
+func (g *Game) CanRedoMovement() bool {
+	return len(g.redoMovements) > 0
+}
+
 // StartingFen returns the Forsyth–Edwards Notation of the game's
 // starting position.
 //
@@ -249,25 +381,21 @@ func (g *Game) MovementHistory() []Movement {
 	return g.movementHistory
 }
 
-// Outcome represent's the game's outcome. That is, the reason
-// of an ended game.
-//
-// For a non-ended game, Outcome will be Outcome_None.
-type Outcome string
+// DrawMode represents how a Game handles the threefold repetition
+// and fifty move rule draws.
+type DrawMode uint8
 
 const (
-	Outcome_None            Outcome = "None"
-	Outcome_Checkmate_White         = "Checkmate: White wins"
-	Outcome_Checkmate_Black         = "Checkmate: Black wins"
-
-	Outcome_Draw_Stalemate = "Draw: Stalemate"
-	Outcome_Draw_50Move    = "Draw: Fifty move rule"
-	Outcome_Draw_3Rep      = "Draw: Threefold repetition"
+	DrawMode_Automatic DrawMode = iota // Threefold repetition and fifty move rule end the game automatically
+	DrawMode_Claim                     // Threefold repetition and fifty move rule must be claimed via ClaimDraw()
 )
 
 // Outcome returns's the game's outcome.
 //
 // For a non-ended game, Outcome will be Outcome_None
+//
+// In timed games, a player running out of time is not detected by Outcome,
+// but by the next movement or a call to CheckTimeout().
 func (g *Game) Outcome() Outcome {
 	return g.outcome
 }
@@ -276,216 +404,198 @@ func (g *Game) Outcome() Outcome {
 // the passed argument.
 func (g *Game) Terminate(outcome Outcome) {
 	g.outcome = outcome
-}
 
-func (g *Game) filterPseudoMovements(movements *[]Movement) []Movement {
-	//beginningColor := b.playerToMove
-	filteredMovements := []Movement{}
-
-	// Ensure we use this colors (and not others, as CurrentPosition will change on GetPseudoMovements)
-	allyColor := g.currentPosition.playerToMove
-	opponentColor := g.currentPosition.playerToMove.Opposite()
-
-	for _, myMovement := range *movements {
-		g.simulateMovement(myMovement)
-		_, opponentAttackMatrix := g.currentPosition.computePseudoMovements(opponentColor, false)
-
-		weGetChecked := g.currentPosition.checkForCheck(allyColor, &opponentAttackMatrix)
+	if g.clock != nil && outcome != Outcome_None {
+		g.clock.Stop()
+	}
+}
 
-		if !weGetChecked {
-			filteredMovements = append(filteredMovements, myMovement)
-		}
+// Resign ends the game by resignation of the passed color (player/side).
+// Once resigned, movements are no longer accepted.
+//
+// If the game has already ended, it will return an error.
+func (g *Game) Resign(color Color) error {
+	if g.outcome != Outcome_None {
+		return errors.New("The game has already ended.")
+	}
 
-		g.undoSimulatedMovement()
+	if color == Color_White {
+		g.Terminate(Outcome_Resignation_Black)
+	} else if color == Color_Black {
+		g.Terminate(Outcome_Resignation_White)
+	} else {
+		return errors.New("Only white or black can resign.")
 	}
 
-	return filteredMovements
+	g.drawOfferedBy = Color_None
+	return nil
 }
 
-func (g *Game) computeLegalMovements() {
-	// Compute pseudo movements & filter illegal movements
-	pseudoMovements, _ := g.currentPosition.computePseudoMovements(g.currentPosition.playerToMove, true)
-	legalMovements := g.filterPseudoMovements(&pseudoMovements)
-	g.computedLegalMovements = legalMovements
+// OfferDraw makes a draw offer from the passed color (player/side) to its
+// opponent, who can accept it via AcceptDraw() or decline it via DeclineDraw().
+//
+// The offer expires once the opponent makes a movement.
+//
+// If the game has already ended, it will return an error.
+func (g *Game) OfferDraw(color Color) error {
+	if g.outcome != Outcome_None {
+		return errors.New("The game has already ended.")
+	}
+	if color != Color_White && color != Color_Black {
+		return errors.New("Only white or black can offer a draw.")
+	}
 
-	// Then, set current position isChecked if current turn is under check
-	_, opponentAttackMatrix := g.currentPosition.computePseudoMovements(g.currentPosition.playerToMove.Opposite(), false)
-	isChecked := g.currentPosition.checkForCheck(g.currentPosition.playerToMove, &opponentAttackMatrix)
-	g.currentPosition.isChecked = isChecked
+	g.drawOfferedBy = color
+	return nil
 }
 
-// Used by perft
-func (g *Game) simulateMovement(movement Movement) {
-	g.forceMovement(movement, false)
+// DrawOffer returns the color (player/side) that has a pending draw offer.
+//
+// If there is no pending draw offer, it will return Color_None.
+func (g *Game) DrawOffer() Color {
+	return g.drawOfferedBy
 }
 
-func (g *Game) forceMovement(movement Movement, recomputeLegalMovements bool) {
-	newPosition := g.currentPosition.clone()
-
-	if movement.isQueenSideCastling || movement.isKingSideCastling {
-		newPosition.castlingRights.queenSide[movement.movingPiece.Color] = false
-		newPosition.castlingRights.kingSide[movement.movingPiece.Color] = false
-
-		castlingRow := 7
-		if movement.movingPiece.Color == Color_Black {
-			castlingRow = 0
-		}
-
-		if movement.isQueenSideCastling {
-			rookPiece := newPosition.board[castlingRow][0]
-			kingPiece := newPosition.board[castlingRow][4]
+// AcceptDraw accepts the pending draw offer, ending the game by agreement.
+// Once the draw is agreed, movements are no longer accepted.
+//
+// If there is no pending draw offer, it will return an error.
+func (g *Game) AcceptDraw() error {
+	if g.drawOfferedBy == Color_None || g.outcome != Outcome_None {
+		return errors.New("There is no draw offer to accept.")
+	}
 
-			// Set new rook
-			newPosition.board[castlingRow][3].Kind = rookPiece.Kind
-			newPosition.board[castlingRow][3].Color = rookPiece.Color
+	g.drawOfferedBy = Color_None
+	g.Terminate(Outcome_Draw_Agreement)
+	return nil
+}
 
-			// Delete old rook
-			newPosition.board[castlingRow][0].Kind = Kind_None
-			newPosition.board[castlingRow][0].Color = Color_None
+// DeclineDraw declines the pending draw offer.
+//
+// If there is no pending draw offer, it will return an error.
+func (g *Game) DeclineDraw() error {
+	if g.drawOfferedBy == Color_None {
+		return errors.New("There is no draw offer to decline.")
+	}
 
-			// Set new king
-			newPosition.board[castlingRow][2].Kind = kingPiece.Kind
-			newPosition.board[castlingRow][2].Color = kingPiece.Color
+	g.drawOfferedBy = Color_None
+	return nil
+}
 
-			// Delete old king
-			newPosition.board[castlingRow][4].Kind = Kind_None
-			newPosition.board[castlingRow][4].Color = Color_None
-		} else if movement.isKingSideCastling {
-			rookPiece := newPosition.board[castlingRow][7]
-			kingPiece := newPosition.board[castlingRow][4]
+// SetDrawMode sets how the game handles the threefold repetition
+// and fifty move rule draws. By default, DrawMode_Automatic is used.
+//
+// With DrawMode_Claim, they must be claimed via ClaimDraw(), as in FIDE
+// rules, and the game only ends automatically on a fivefold repetition
+// or on the seventy-five move rule.
+func (g *Game) SetDrawMode(mode DrawMode) {
+	g.drawMode = mode
+}
 
-			// Set new rook
-			newPosition.board[castlingRow][5].Kind = rookPiece.Kind
-			newPosition.board[castlingRow][5].Color = rookPiece.Color
+// DrawMode returns how the game handles the threefold repetition
+// and fifty move rule draws.
+func (g *Game) DrawMode() DrawMode {
+	return g.drawMode
+}
 
-			// Delete old rook
-			newPosition.board[castlingRow][7].Kind = Kind_None
-			newPosition.board[castlingRow][7].Color = Color_None
+// CanClaimDraw returns the draws that the player to move can claim
+// in the current position: Outcome_Draw_3Rep and/or Outcome_Draw_50Move.
+//
+// If the game has ended or no draw can be claimed, it will return an empty list.
+func (g *Game) CanClaimDraw() []Outcome {
+	claimableDraws := make([]Outcome, 0, 2)
+	if g.outcome != Outcome_None {
+		return claimableDraws
+	}
 
-			// Set new king
-			newPosition.board[castlingRow][6].Kind = kingPiece.Kind
-			newPosition.board[castlingRow][6].Color = kingPiece.Color
+	if g.RepetitionCount() >= 3 {
+		claimableDraws = append(claimableDraws, Outcome_Draw_3Rep)
+	}
+	if g.currentPosition.halfmoveClock >= 100 {
+		claimableDraws = append(claimableDraws, Outcome_Draw_50Move)
+	}
 
-			// Delete old king
-			newPosition.board[castlingRow][4].Kind = Kind_None
-			newPosition.board[castlingRow][4].Color = Color_None
-		}
-	} else {
-		if movement.movingPiece.Kind == Kind_Pawn {
-			if movement.isDoublePawnPush {
-				invertSum := -1
-				if movement.movingPiece.Color == Color_Black {
-					invertSum = +1
-				}
+	return claimableDraws
+}
 
-				// Uint8 from that sum/rest, as it will never be negative in a starting double pawn
-				newEnPassantSquare := newSquare(uint8(int(movement.fromSq.I)+invertSum), movement.fromSq.J)
-				newPosition.enPassantSq = &newEnPassantSquare
-			}
-		} else if movement.movingPiece.Kind == Kind_King {
-			newPosition.castlingRights.queenSide[movement.movingPiece.Color] = false
-			newPosition.castlingRights.kingSide[movement.movingPiece.Color] = false
-		} else if movement.movingPiece.Kind == Kind_Rook {
-			// Check if currently moving rook is from queen or king side
-			if newPosition.castlingRights.queenSide[movement.movingPiece.Color] {
-				if movement.movingPiece.Square.J == 0 {
-					newPosition.castlingRights.queenSide[movement.movingPiece.Color] = false
-				}
-			}
-			if newPosition.castlingRights.kingSide[movement.movingPiece.Color] {
-				if movement.movingPiece.Square.J == 7 {
-					newPosition.castlingRights.kingSide[movement.movingPiece.Color] = false
-				}
-			}
+// ClaimDraw ends the game with the passed draw, if it can be claimed
+// in the current position.
+//
+// If the game has ended or the draw can not be claimed, it will return an error.
+func (g *Game) ClaimDraw(reason Outcome) error {
+	for _, claimableDraw := range g.CanClaimDraw() {
+		if claimableDraw == reason {
+			g.Terminate(reason)
+			return nil
 		}
+	}
 
-		if movement.isTakingPiece {
-			newPosition.board[movement.takingPiece.Square.I][movement.takingPiece.Square.J].Kind = Kind_None
-			newPosition.board[movement.takingPiece.Square.I][movement.takingPiece.Square.J].Color = Color_None
+	return errors.New("That draw can not be claimed in the current position.")
+}
 
-			if recomputeLegalMovements {
-				newPosition.captures = append(newPosition.captures, movement.takingPiece)
-			}
+// RepetitionCount returns the amount of times the current position has
+// occurred in the game, including the current occurrence.
+//
+// Two positions are considered the same if they have the same piece placement,
+// player to move, castling rights and en passant capture possibilities, no
+// matter their halfmove clock or fullmove counter.
+func (g *Game) RepetitionCount() int {
+	return int(g.positionMap[g.repetitionKey()])
+}
 
-			if movement.takingPiece.Kind == Kind_Rook {
-				if newPosition.castlingRights.queenSide[movement.takingPiece.Color] {
-					castlingRow := uint8(7)
-					if movement.takingPiece.Color == Color_Black {
-						castlingRow = 0
-					}
+// repetitionKey returns the key that identifies the current position for
+// repetition detection. It assumes that the legal movements are computed.
+//
+// The en passant square is only included if it can be legally captured,
+// whereas the position's hash includes it if it can be pseudo-legally captured.
+func (g *Game) repetitionKey() uint64 {
+	enPassantKey := g.currentPosition.enPassantKey()
+	if enPassantKey == 0 {
+		return g.currentPosition.hash
+	}
 
-					if movement.takingPiece.Square.I == castlingRow && movement.takingPiece.Square.J == 0 {
-						newPosition.castlingRights.queenSide[movement.takingPiece.Color] = false
-					}
-				}
-				if newPosition.castlingRights.kingSide[movement.takingPiece.Color] {
-					castlingRow := uint8(7)
-					if movement.takingPiece.Color == Color_Black {
-						castlingRow = 0
-					}
-
-					if movement.takingPiece.Square.I == castlingRow && movement.takingPiece.Square.J == 7 {
-						newPosition.castlingRights.kingSide[movement.takingPiece.Color] = false
-					}
-				}
-			}
+	for _, legalMovement := range g.computedLegalMovements {
+		if legalMovement.movingPiece.Kind == Kind_Pawn && legalMovement.isTakingPiece &&
+			!legalMovement.takingPiece.Square.IsEqualTo(legalMovement.toSq) {
+			return g.currentPosition.hash
 		}
+	}
 
-		newPosition.board[movement.toSq.I][movement.toSq.J].Color = movement.movingPiece.Color
-		if movement.pawnPromotionTo == nil {
-			// Update data of the new piece
-			newPosition.board[movement.toSq.I][movement.toSq.J].Kind = movement.movingPiece.Kind
-		} else {
-			// Promote the piece
-			newPosition.board[movement.toSq.I][movement.toSq.J].Kind = *movement.pawnPromotionTo
-		}
+	return g.currentPosition.hash ^ enPassantKey
+}
 
-		// Delete this piece's previous position
-		newPosition.board[movement.fromSq.I][movement.fromSq.J].Kind = Kind_None
-		newPosition.board[movement.fromSq.I][movement.fromSq.J].Color = Color_None
-	}
+func (g *Game) computeLegalMovements() {
+	g.computedLegalMovements = g.currentPosition.legalMovements()
 
-	// Handle halfmove clock
-	if movement.movingPiece.Kind == Kind_Pawn || movement.isTakingPiece {
-		newPosition.halfmoveClock = 0
-	} else {
-		newPosition.halfmoveClock++
-	}
+	// Then, set current position isChecked if current turn is under check
+	g.currentPosition.isChecked = g.currentPosition.isInCheck()
+}
 
-	// Handle fullmove counter
-	if movement.movingPiece.Color == Color_White {
-		newPosition.playerToMove = Color_Black
-	} else if movement.movingPiece.Color == Color_Black {
-		newPosition.playerToMove = Color_White
-		newPosition.fullmoveCounter++
-	}
+// simulateMovement makes the movement in place, without recomputing legal
+// movements nor the outcome. It must be undone via undoSimulatedMovement().
+func (g *Game) simulateMovement(movement Movement) undoRecord {
+	return g.currentPosition.makeMovement(movement)
+}
+
+func (g *Game) forceMovement(movement Movement, recomputeLegalMovements bool) {
+	// Captures are only tracked after making a move (not when simulating)
+	newPosition := g.currentPosition.applyMovement(movement, recomputeLegalMovements)
 
 	// Switch positions
 	g.positions = append(g.positions, g.currentPosition)
 	g.currentPosition = newPosition
 
-	if recomputeLegalMovements {
-		if _, ok := g.positionMap[g.currentPosition.Fen()]; !ok {
-			g.positionMap[g.currentPosition.Fen()] = 1
-		} else {
-			g.positionMap[g.currentPosition.Fen()]++
-			if g.positionMap[g.currentPosition.Fen()] == 3 {
-				g.Terminate(Outcome_Draw_3Rep)
-			}
-		}
-	}
-
 	// Recomputing will take place:
 	// 		- After making a move (via game.MakeMove())
 	// 		- Manually via computeLegalMovements(), called by Perft
 	if recomputeLegalMovements {
 		g.computeLegalMovements()
 
-		if len(g.computedLegalMovements) == 0 {
-			_, opponentAttackMatrix := g.currentPosition.computePseudoMovements(g.currentPosition.playerToMove.Opposite(), false)
-			isGettingChecked := g.currentPosition.checkForCheck(g.currentPosition.playerToMove, &opponentAttackMatrix)
+		// Legal movements are needed to know if the en passant is capturable
+		g.positionMap[g.repetitionKey()]++
 
-			if isGettingChecked {
+		if len(g.computedLegalMovements) == 0 {
+			if g.currentPosition.isChecked {
 				if g.currentPosition.playerToMove == Color_White {
 					g.Terminate(Outcome_Checkmate_Black)
 				} else {
@@ -494,17 +604,20 @@ func (g *Game) forceMovement(movement Movement, recomputeLegalMovements bool) {
 			} else {
 				g.Terminate(Outcome_Draw_Stalemate)
 			}
-		} else if g.currentPosition.halfmoveClock >= 100 {
+		} else if g.RepetitionCount() >= 5 {
+			g.Terminate(Outcome_Draw_5Rep)
+		} else if g.RepetitionCount() >= 3 && g.drawMode == DrawMode_Automatic {
+			g.Terminate(Outcome_Draw_3Rep)
+		} else if g.currentPosition.HasInsufficientMaterial(Color_White) && g.currentPosition.HasInsufficientMaterial(Color_Black) {
+			g.Terminate(Outcome_Draw_InsufficientMaterial)
+		} else if g.currentPosition.halfmoveClock >= 150 {
+			g.Terminate(Outcome_Draw_75Move)
+		} else if g.currentPosition.halfmoveClock >= 100 && g.drawMode == DrawMode_Automatic {
 			g.Terminate(Outcome_Draw_50Move)
 		}
 	}
 }
 
-func (g *Game) undoSimulatedMovement() {
-	if len(g.positions) > 0 {
-		g.currentPosition = g.positions[len(g.positions)-1]
-		g.positions = g.positions[:len(g.positions)-1]
-	} else {
-		panic("Cannot undo more. Game has no more positions.")
-	}
+func (g *Game) undoSimulatedMovement(movement Movement, undo undoRecord) {
+	g.currentPosition.unmakeMovement(movement, undo)
 }
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"slices"
-	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -57,29 +56,27 @@ func parsePerftFile() {
 			continue
 		}
 
-		parts := strings.SplitN(lineText, ";", 2)
+		epd, err := ParseEPD(lineText)
+		if err != nil {
+			panic(fmt.Errorf("There was an error parsing the perft test \"%s\": %w", lineText, err))
+		}
 
-		fen := strings.TrimSpace(parts[0])
-		depths := strings.Split(strings.TrimSpace(parts[1]), ";")
+		nodes, err := epd.PerftNodes()
+		if err != nil {
+			panic(fmt.Errorf("There was an error parsing the depths of \"%s\": %w", lineText, err))
+		}
 
+		fen := epd.Position.Fen()
 		depthMap := make(map[int]int)
 
 		maxDepthFound := 0
 
-		for _, depth := range depths {
-			depthParts := strings.Split(strings.TrimSpace(depth), " ")
-			if len(depthParts) != 2 {
-				panic(fmt.Errorf("There was an error parsing the depth \"%s\".", depth))
-			}
-
-			depthAmount, _ := strconv.Atoi(string(depthParts[0][1]))
-			nodeAmount, _ := strconv.Atoi(depthParts[1])
-
+		for depthAmount, nodeAmount := range nodes {
 			if depthAmount > maxDepthFound && depthAmount <= maxDepth {
 				maxDepthFound = depthAmount
 			}
 
-			depthMap[depthAmount] = nodeAmount
+			depthMap[depthAmount] = int(nodeAmount)
 		}
 
 		loadedPerftTests = append(loadedPerftTests, PerftTest{
@@ -104,6 +101,26 @@ func parsePerftFile() {
 	fmt.Printf("Running %d Perfts at max-depth of %d\n", len(loadedPerftTests), maxDepth)
 }
 
+func TestFenCastlingRights(t *testing.T) {
+	fens := []string{
+		"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R b Qk - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R w K - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R w - - 0 1",
+	}
+
+	for _, fen := range fens {
+		game, err := NewGame(fen)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if game.CurrentFen() != fen {
+			t.Errorf("Expected the FEN %q, got %q", fen, game.CurrentFen())
+		}
+	}
+}
+
 func TestPerft(t *testing.T) {
 	parsePerftFile()
 
@@ -127,7 +144,20 @@ func TestPerft(t *testing.T) {
 						fmt.Printf("Evaluation depth: %d\n", depth)
 					}
 
-					result := game.perft(depth, depth, "", positionVerbose)
+					if positionVerbose {
+						divide := game.PerftDivide(depth)
+						movements := make([]string, 0, len(divide))
+						for movement := range divide {
+							movements = append(movements, movement)
+						}
+						slices.Sort(movements)
+
+						for _, movement := range movements {
+							fmt.Printf("\t%s: %d\n", movement, divide[movement])
+						}
+					}
+
+					result := int(game.Perft(depth))
 					totalNodes += result
 
 					if val, ok := perftTest.depthMap[depth]; ok && val != result {
@@ -144,3 +174,226 @@ func TestPerft(t *testing.T) {
 		fmt.Printf("Nodes per second: %fkN/s\n", kNodesPerSecond)
 	}
 }
+
+func TestUndoRedoMovement(t *testing.T) {
+	game, _ := NewGame("")
+	if err := game.UndoMovement(); err == nil {
+		t.Fatalf("Expected an error when undoing without movements")
+	}
+
+	for _, san := range []string{"e4", "f5", "exf5", "g5", "Qh5#"} {
+		game.MakeMovementSAN(san)
+	}
+	finalFen := game.CurrentFen()
+
+	if game.Outcome() != Outcome_Checkmate_White {
+		t.Fatalf("Expected checkmate, got %s", game.Outcome())
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := game.UndoMovement(); err != nil {
+			t.Fatalf("Unexpected error undoing: %s", err)
+		}
+	}
+
+	if game.Outcome() != Outcome_None || game.CurrentPositionIndex() != 2 || len(game.MovementHistory()) != 2 {
+		t.Errorf("Undo did not restore the game state: %s, %d", game.Outcome(), game.CurrentPositionIndex())
+	}
+	if len(game.CurrentPosition().Captures()) != 0 || len(game.LegalMovements()) != 31 {
+		t.Errorf("Undo did not restore the position: %s", game.CurrentFen())
+	}
+
+	for game.CanRedoMovement() {
+		if err := game.RedoMovement(); err != nil {
+			t.Fatalf("Unexpected error redoing: %s", err)
+		}
+	}
+
+	if game.CurrentFen() != finalFen || game.Outcome() != Outcome_Checkmate_White {
+		t.Errorf("Redo did not restore the game state: %s, %s", game.CurrentFen(), game.Outcome())
+	}
+
+	game.UndoMovement()
+	game.UndoMovement()
+	game.MakeMovementSAN("Nc6")
+	if game.CanRedoMovement() {
+		t.Errorf("Expected the redo stack to be discarded after a different movement")
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen          string
+		white, black bool
+	}{
+		{"", false, false},
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true, true},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", true, true},
+		{"4k3/8/8/8/8/8/8/1N2K3 w - - 0 1", true, true},
+		{"4k3/8/8/8/8/8/8/NN2K3 w - - 0 1", false, true},
+		{"2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false, false},
+		{"3bk3/8/8/8/8/8/8/2B1K3 w - - 0 1", true, true},
+		{"4k3/8/8/8/8/8/8/1NB1K3 w - - 0 1", false, true},
+		{"4k3/4p3/8/8/8/8/8/1N2K3 w - - 0 1", false, false},
+		{"4kq2/8/8/8/8/8/8/1N2K3 w - - 0 1", true, false},
+		{"4kr2/8/8/8/8/8/8/2B1K3 w - - 0 1", false, false},
+	}
+
+	for _, test := range tests {
+		game, _ := NewGame(test.fen)
+		position := game.CurrentPosition()
+		if position.HasInsufficientMaterial(Color_White) != test.white || position.HasInsufficientMaterial(Color_Black) != test.black {
+			t.Errorf("Unexpected insufficient material in %q", test.fen)
+		}
+	}
+
+	// A lone knight can mate when a rook blocks the king, but never a queen,
+	// as it can always take the knight
+	for fen, isCheckmate := range map[string]bool{
+		"kr6/2N5/1K6/8/8/8/8/8 b - - 0 1": true,
+		"kq6/2N5/1K6/8/8/8/8/8 b - - 0 1": false,
+	} {
+		game, _ := NewGame(fen)
+		position := game.CurrentPosition()
+		if position.IsCheckmate() != isCheckmate || position.HasInsufficientMaterial(Color_White) == isCheckmate {
+			t.Errorf("Unexpected checkmate or insufficient material in %q", fen)
+		}
+	}
+
+	game, _ := NewGame("4k3/8/8/8/8/8/3q4/4K3 w - - 0 1")
+	game.MakeMovementAlgebraic("e1d2")
+	if game.Outcome() != Outcome_Draw_InsufficientMaterial {
+		t.Errorf("Expected a draw by insufficient material, got %s", game.Outcome())
+	}
+}
+
+func TestRepetition(t *testing.T) {
+	game, _ := NewGame("")
+	for _, algebraic := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		game.MakeMovementAlgebraic(algebraic)
+	}
+	if game.RepetitionCount() != 2 || game.Outcome() != Outcome_None {
+		t.Fatalf("Expected the starting position to occur twice, got %d", game.RepetitionCount())
+	}
+
+	for _, algebraic := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		game.MakeMovementAlgebraic(algebraic)
+	}
+	if game.RepetitionCount() != 3 || game.Outcome() != Outcome_Draw_3Rep {
+		t.Errorf("Expected a threefold repetition, got %d, %s", game.RepetitionCount(), game.Outcome())
+	}
+
+	game.UndoMovement()
+	game.UndoMovement()
+	if game.RepetitionCount() != 2 || game.Outcome() != Outcome_None {
+		t.Errorf("Expected undo to restore the repetition count, got %d", game.RepetitionCount())
+	}
+
+	// En passant squares that can not be captured are ignored
+	doublePush, _ := NewGame("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	doublePush.MakeMovementAlgebraic("e2e4")
+	noEnPassant, _ := NewGame("4k3/8/8/8/4P3/8/8/4K3 b - - 0 1")
+	if doublePush.repetitionKey() != noEnPassant.repetitionKey() {
+		t.Errorf("Expected %q and %q to be the same position", doublePush.CurrentFen(), noEnPassant.CurrentFen())
+	}
+
+	doublePush, _ = NewGame("4k3/8/8/8/3p4/8/4P3/4K3 w - - 0 1")
+	doublePush.MakeMovementAlgebraic("e2e4")
+	noEnPassant, _ = NewGame("4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1")
+	if doublePush.repetitionKey() == noEnPassant.repetitionKey() {
+		t.Errorf("Expected %q and %q to be different positions", doublePush.CurrentFen(), noEnPassant.CurrentFen())
+	}
+}
+
+func TestClaimDraw(t *testing.T) {
+	game, _ := NewGame("")
+	game.SetDrawMode(DrawMode_Claim)
+
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	for i := 0; i < 2; i++ {
+		for _, algebraic := range shuffle {
+			game.MakeMovementAlgebraic(algebraic)
+		}
+	}
+
+	if game.Outcome() != Outcome_None {
+		t.Fatalf("Expected the game to continue after a threefold repetition, got %s", game.Outcome())
+	}
+	if claimable := game.CanClaimDraw(); len(claimable) != 1 || claimable[0] != Outcome_Draw_3Rep {
+		t.Fatalf("Expected a claimable threefold repetition, got %v", claimable)
+	}
+	if err := game.ClaimDraw(Outcome_Draw_50Move); err == nil {
+		t.Errorf("Expected an error claiming a fifty move rule draw")
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, algebraic := range shuffle {
+			game.MakeMovementAlgebraic(algebraic)
+		}
+	}
+	if game.Outcome() != Outcome_Draw_5Rep {
+		t.Errorf("Expected a fivefold repetition, got %s", game.Outcome())
+	}
+
+	game.UndoMovement()
+	if err := game.ClaimDraw(Outcome_Draw_3Rep); err != nil || game.Outcome() != Outcome_Draw_3Rep {
+		t.Errorf("Expected the threefold repetition claim to succeed, got %v", err)
+	}
+
+	game, _ = NewGame("4k3/8/8/8/8/8/8/R3K3 w - - 99 80")
+	game.SetDrawMode(DrawMode_Claim)
+	game.MakeMovementAlgebraic("a1a2")
+	if claimable := game.CanClaimDraw(); game.Outcome() != Outcome_None || len(claimable) != 1 || claimable[0] != Outcome_Draw_50Move {
+		t.Errorf("Expected a claimable fifty move rule draw, got %v, %s", claimable, game.Outcome())
+	}
+
+	game, _ = NewGame("4k3/8/8/8/8/8/8/R3K3 w - - 149 80")
+	game.SetDrawMode(DrawMode_Claim)
+	game.MakeMovementAlgebraic("a1a2")
+	if game.Outcome() != Outcome_Draw_75Move {
+		t.Errorf("Expected a seventy-five move rule draw, got %s", game.Outcome())
+	}
+}
+
+func TestResignAndDrawOffers(t *testing.T) {
+	game, _ := NewGame("")
+	if err := game.AcceptDraw(); err == nil {
+		t.Errorf("Expected an error accepting a draw without offer")
+	}
+
+	game.OfferDraw(Color_White)
+	game.MakeMovementAlgebraic("e2e4")
+	if game.DrawOffer() != Color_White {
+		t.Errorf("Expected the draw offer to remain after the offering side moves")
+	}
+	game.MakeMovementAlgebraic("e7e5")
+	if game.DrawOffer() != Color_None {
+		t.Errorf("Expected the draw offer to expire after the opponent moves")
+	}
+
+	game.OfferDraw(Color_White)
+	game.DeclineDraw()
+	if game.DrawOffer() != Color_None || game.Outcome() != Outcome_None {
+		t.Errorf("Expected the draw offer to be declined")
+	}
+
+	game.OfferDraw(Color_Black)
+	if err := game.AcceptDraw(); err != nil || game.Outcome() != Outcome_Draw_Agreement {
+		t.Errorf("Expected a draw by agreement, got %s", game.Outcome())
+	}
+	if err := game.Resign(Color_White); err == nil {
+		t.Errorf("Expected an error resigning an ended game")
+	}
+	if err := game.MakeMovementAlgebraic("g1f3"); err == nil || len(game.MovementHistory()) != 2 {
+		t.Errorf("Expected no movements after a draw by agreement, got %v", err)
+	}
+
+	game, _ = NewGame("")
+	game.Resign(Color_White)
+	if game.Outcome() != Outcome_Resignation_Black || !strings.Contains(game.PGN(), "[Result \"0-1\"]") {
+		t.Errorf("Expected black to win by resignation, got %s", game.Outcome())
+	}
+	if err := game.MakeMovementAlgebraic("e2e4"); err == nil || game.Outcome() != Outcome_Resignation_Black {
+		t.Errorf("Expected no movements after a resignation, got %v", err)
+	}
+}
@@ -41,6 +41,10 @@ var pawnStartingRows = map[Color]uint8{
 	Color_White: 6,
 	Color_Black: 1,
 }
+var castlingRows = map[Color]uint8{
+	Color_White: 7,
+	Color_Black: 0,
+}
 
 var promotableKinds = [4]Kind{Kind_Queen, Kind_Rook, Kind_Bishop, Kind_Knight}
 
@@ -15,14 +15,16 @@ type Movement struct {
 	toSq   Square
 
 	isDoublePawnPush bool
-	pawnPromotionTo  *Kind
+	pawnPromotionTo  Kind // Kind_None if the movement is not a promotion
 
 	isQueenSideCastling bool
 	isKingSideCastling  bool
+	castlingRookSq      Square // Only set in castling movements
+	isChess960          bool   // Castling is written as king takes rook
 }
 
 func (m *Movement) withPawnPromotion(newKind Kind) *Movement {
-	m.pawnPromotionTo = &newKind
+	m.pawnPromotionTo = newKind
 	return m
 }
 
@@ -37,9 +39,11 @@ func (m *Movement) withPawn(isDoublePawnPush bool) *Movement {
 	return m
 }
 
-func (m *Movement) withCastling(isQueenSideMove, isKingSideMove bool) *Movement {
+func (m *Movement) withCastling(isQueenSideMove, isKingSideMove bool, rookSquare Square, isChess960 bool) *Movement {
 	m.isKingSideCastling = isKingSideMove
 	m.isQueenSideCastling = isQueenSideMove
+	m.castlingRookSq = rookSquare
+	m.isChess960 = isChess960
 	return m
 }
 
@@ -54,21 +58,29 @@ func newMovement(movingPiece Piece, fromSquare, toSquare Square) *Movement {
 
 // Algebraic returns the Pure algebraic notation of the movement, as string.
 //
+// In Chess960 positions, castling is written as the king taking its own
+// rook, as in the UCI protocol.
+//
 // Examples outputs:
 //
 //	"d2d3"
 //	"f7f8q"
+//	"e1g1" // Castling
+//	"b1a1" // Chess960 castling
 func (m Movement) Algebraic() string {
 	from := m.fromSq
 	to := m.toSq
+	if m.isChess960 && (m.isQueenSideCastling || m.isKingSideCastling) {
+		to = m.castlingRookSq
+	}
 
 	var sb strings.Builder
 
 	sb.WriteString(from.Algebraic())
 	sb.WriteString(to.Algebraic())
 
-	if m.pawnPromotionTo != nil {
-		sb.WriteRune((*m.pawnPromotionTo).Rune())
+	if m.pawnPromotionTo != Kind_None {
+		sb.WriteRune(m.pawnPromotionTo.Rune())
 	}
 
 	return sb.String()
@@ -114,17 +126,17 @@ func (m Movement) IsDoublePawnPush() bool {
 
 // IsPawnPromotion reports whether the movement promotes a pawn or not.
 func (m Movement) IsPawnPromotion() bool {
-	return m.pawnPromotionTo != nil
+	return m.pawnPromotionTo != Kind_None
 }
 
 // IsPawnPromotion returns the new Kind the pawn is being promoted to.
 //
 // If the movement is not a promotion, it will return Kind_None and the error.
 func (m Movement) PawnPromotion() (Kind, error) {
-	if m.pawnPromotionTo == nil {
+	if m.pawnPromotionTo == Kind_None {
 		return Kind_None, errors.New("This movement does not promote a pawn.")
 	}
-	return *m.pawnPromotionTo, nil
+	return m.pawnPromotionTo, nil
 }
 
 // IsQueenSideCastling reports whether the movement is a queenside castling or not.
@@ -136,3 +148,13 @@ func (m Movement) IsQueenSideCastling() bool {
 func (m Movement) IsKingSideCastling() bool {
 	return m.isKingSideCastling
 }
+
+// CastlingRookSquare returns the initial Square of the castling rook.
+//
+// If the movement is not a castling, it will return an empty Square and the error.
+func (m Movement) CastlingRookSquare() (Square, error) {
+	if !m.isQueenSideCastling && !m.isKingSideCastling {
+		return Square{}, errors.New("This movement is not a castling.")
+	}
+	return m.castlingRookSq, nil
+}
@@ -0,0 +1,179 @@
+package chess
+
+// Outcome represent's the game's outcome. That is, the reason
+// of an ended game.
+//
+// For a non-ended game, Outcome will be Outcome_None.
+type Outcome string
+
+// The outcomes up to Outcome_Draw_Agreement are untyped string constants, so
+// they keep working where a string is expected.
+const (
+	Outcome_None            Outcome = "None"
+	Outcome_Checkmate_White         = "Checkmate: White wins"
+	Outcome_Checkmate_Black         = "Checkmate: Black wins"
+
+	Outcome_Draw_Stalemate = "Draw: Stalemate"
+	Outcome_Draw_50Move    = "Draw: Fifty move rule"
+	Outcome_Draw_3Rep      = "Draw: Threefold repetition"
+
+	Outcome_Draw_InsufficientMaterial = "Draw: Insufficient material"
+
+	Outcome_Draw_5Rep   = "Draw: Fivefold repetition"
+	Outcome_Draw_75Move = "Draw: Seventy-five move rule"
+
+	Outcome_Resignation_White = "Resignation: White wins"
+	Outcome_Resignation_Black = "Resignation: Black wins"
+	Outcome_Draw_Agreement    = "Draw: Agreement"
+
+	Outcome_Timeout_White Outcome = "Timeout: White wins"
+	Outcome_Timeout_Black Outcome = "Timeout: Black wins"
+	Outcome_Draw_Timeout  Outcome = "Draw: Timeout against insufficient material"
+
+	Outcome_Adjudication_White Outcome = "Adjudication: White wins"
+	Outcome_Adjudication_Black Outcome = "Adjudication: Black wins"
+	Outcome_Draw_Adjudication  Outcome = "Draw: Adjudication"
+
+	Outcome_Unknown_White Outcome = "Unknown: White wins"
+	Outcome_Unknown_Black Outcome = "Unknown: Black wins"
+	Outcome_Draw_Unknown  Outcome = "Draw: Unknown"
+)
+
+// Method represents the way a game ended.
+type Method uint8
+
+const (
+	Method_None                 Method = iota // The game has not ended
+	Method_Checkmate                          // Checkmate
+	Method_Resignation                        // A player resigned
+	Method_Timeout                            // A player ran out of time
+	Method_Stalemate                          // Stalemate
+	Method_Repetition                         // Threefold or fivefold repetition
+	Method_MoveRule                           // Fifty or seventy-five move rule
+	Method_Agreement                          // Both players agreed to a draw
+	Method_InsufficientMaterial               // No player can checkmate
+	Method_Adjudication                       // An arbiter decided the result
+	Method_Unknown                            // The game ended, but the way it did is not known
+)
+
+// String returns the string/words of the method.
+//
+// Examples:
+//
+//	Method_None.String()      // returns "none"
+//	Method_Checkmate.String() // returns "checkmate"
+//	Method_MoveRule.String()  // returns "move rule"
+//	Method_Unknown.String()   // returns "unknown"
+func (m Method) String() string {
+	names := [11]string{"none", "checkmate", "resignation", "timeout", "stalemate", "repetition", "move rule", "agreement", "insufficient material", "adjudication", "unknown"}
+	if int(m) >= len(names) {
+		return "unknown"
+	}
+	return names[m]
+}
+
+type outcomeResult struct {
+	method Method
+	winner Color
+}
+
+var outcomeResults = map[Outcome]outcomeResult{
+	Outcome_None:            {Method_None, Color_None},
+	Outcome_Checkmate_White: {Method_Checkmate, Color_White},
+	Outcome_Checkmate_Black: {Method_Checkmate, Color_Black},
+
+	Outcome_Draw_Stalemate: {Method_Stalemate, Color_None},
+	Outcome_Draw_50Move:    {Method_MoveRule, Color_None},
+	Outcome_Draw_3Rep:      {Method_Repetition, Color_None},
+
+	Outcome_Draw_InsufficientMaterial: {Method_InsufficientMaterial, Color_None},
+
+	Outcome_Draw_5Rep:   {Method_Repetition, Color_None},
+	Outcome_Draw_75Move: {Method_MoveRule, Color_None},
+
+	Outcome_Resignation_White: {Method_Resignation, Color_White},
+	Outcome_Resignation_Black: {Method_Resignation, Color_Black},
+	Outcome_Draw_Agreement:    {Method_Agreement, Color_None},
+
+	Outcome_Timeout_White: {Method_Timeout, Color_White},
+	Outcome_Timeout_Black: {Method_Timeout, Color_Black},
+	Outcome_Draw_Timeout:  {Method_Timeout, Color_None},
+
+	Outcome_Adjudication_White: {Method_Adjudication, Color_White},
+	Outcome_Adjudication_Black: {Method_Adjudication, Color_Black},
+	Outcome_Draw_Adjudication:  {Method_Adjudication, Color_None},
+
+	Outcome_Unknown_White: {Method_Unknown, Color_White},
+	Outcome_Unknown_Black: {Method_Unknown, Color_Black},
+	Outcome_Draw_Unknown:  {Method_Unknown, Color_None},
+}
+
+// NewOutcome returns the Outcome of a game ended via the passed method, with
+// the passed winner. For draws, the winner must be Color_None.
+//
+// If there is no such outcome, it will return Outcome_None.
+//
+// Examples:
+//
+//	NewOutcome(Method_Resignation, Color_Black) // returns Outcome_Resignation_Black
+//	NewOutcome(Method_Agreement, Color_None)    // returns Outcome_Draw_Agreement
+//	NewOutcome(Method_Stalemate, Color_White)   // returns Outcome_None
+func NewOutcome(method Method, winner Color) Outcome {
+	// Repetition and move rule draws are ambiguous, return the claimable ones
+	if method == Method_Repetition && winner == Color_None {
+		return Outcome_Draw_3Rep
+	} else if method == Method_MoveRule && winner == Color_None {
+		return Outcome_Draw_50Move
+	}
+
+	for outcome, result := range outcomeResults {
+		if result.method == method && result.winner == winner {
+			return outcome
+		}
+	}
+
+	return Outcome_None
+}
+
+// Winner returns the color (player/side) that won the game.
+//
+// For draws and non-ended games, it will return Color_None.
+func (o Outcome) Winner() Color {
+	return outcomeResults[o].winner
+}
+
+// Method returns the way the game ended.
+//
+// For non-ended games, it will return Method_None.
+func (o Outcome) Method() Method {
+	return outcomeResults[o].method
+}
+
+// IsDraw reports whether the game ended in a draw.
+func (o Outcome) IsDraw() bool {
+	return o.Method() != Method_None && o.Winner() == Color_None
+}
+
+// PGNResult returns the game termination marker of the outcome, as used
+// in Portable Game Notation.
+//
+// Examples:
+//
+//	Outcome_Timeout_White.PGNResult() // returns "1-0"
+//	Outcome_Timeout_Black.PGNResult() // returns "0-1"
+//	Outcome_Draw_Timeout.PGNResult()  // returns "1/2-1/2"
+//	Outcome_None.PGNResult()          // returns "*"
+func (o Outcome) PGNResult() string {
+	if o.IsDraw() {
+		return "1/2-1/2"
+	}
+
+	switch o.Winner() {
+	case Color_White:
+		return "1-0"
+	case Color_Black:
+		return "0-1"
+	default:
+		return "*"
+	}
+}
@@ -0,0 +1,43 @@
+package chess
+
+import "testing"
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		outcome   Outcome
+		winner    Color
+		method    Method
+		isDraw    bool
+		pgnResult string
+	}{
+		{Outcome_None, Color_None, Method_None, false, "*"},
+		{Outcome_Checkmate_White, Color_White, Method_Checkmate, false, "1-0"},
+		{Outcome_Resignation_Black, Color_Black, Method_Resignation, false, "0-1"},
+		{Outcome_Timeout_White, Color_White, Method_Timeout, false, "1-0"},
+		{Outcome_Draw_Timeout, Color_None, Method_Timeout, true, "1/2-1/2"},
+		{Outcome_Draw_5Rep, Color_None, Method_Repetition, true, "1/2-1/2"},
+		{Outcome_Draw_InsufficientMaterial, Color_None, Method_InsufficientMaterial, true, "1/2-1/2"},
+		{Outcome_Unknown_Black, Color_Black, Method_Unknown, false, "0-1"},
+	}
+
+	for _, test := range tests {
+		if test.outcome.Winner() != test.winner || test.outcome.Method() != test.method ||
+			test.outcome.IsDraw() != test.isDraw || test.outcome.PGNResult() != test.pgnResult {
+			t.Errorf("Unexpected result for %q: %s, %s, %t, %s", test.outcome, test.outcome.Winner(),
+				test.outcome.Method(), test.outcome.IsDraw(), test.outcome.PGNResult())
+		}
+	}
+
+	if NewOutcome(Method_Adjudication, Color_Black) != Outcome_Adjudication_Black {
+		t.Errorf("Unexpected outcome from method and winner")
+	}
+	if Method(100).String() != "unknown" {
+		t.Errorf("Expected unknown methods to have a fallback string, got %q", Method(100).String())
+	}
+
+	// The original outcomes can still be used as strings
+	var reason string = Outcome_Draw_3Rep
+	if reason != "Draw: Threefold repetition" {
+		t.Errorf("Unexpected outcome string %q", reason)
+	}
+}
@@ -1,35 +1,385 @@
 package chess
 
 import (
-	"fmt"
+	"context"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
 )
 
-// Used for testing via game_test.go
-func (g *Game) perft(initialDepth, depth int, currentMove string, positionVerbose bool) int {
-	var nMoves, i int
-	nodes := 0
+// The maximum amount of legal movements of any position is 218, so a
+// buffer of this capacity never grows.
+const maxLegalMovements = 256
 
-	if depth == 0 {
-		if positionVerbose && initialDepth == 1 {
-			fmt.Printf("\t%s: %d\n", currentMove, 1)
-		}
+// PerftStats represents the detailed results of a perft, as in the
+// chessprogramming reference tables. All the counts, except Nodes, refer
+// to the movements that lead to the leaf nodes.
+type PerftStats struct {
+	Nodes            uint64
+	Captures         uint64 // Including en passants
+	EnPassants       uint64
+	Castles          uint64
+	Promotions       uint64
+	Checks           uint64 // Including discovered and double checks
+	DiscoveredChecks uint64 // Checks given only by pieces other than the moved one
+	DoubleChecks     uint64
+	Checkmates       uint64
+}
+
+// Perft (performance test) returns the amount of leaf nodes of the legal
+// movement tree of the current position, up to the passed depth. It is used
+// to validate the movement generation against known results.
+//
+// The game is not modified.
+//
+// Example:
+//
+//	game, _ := NewGame("")
+//	game.Perft(3) // returns 8902
+func (g *Game) Perft(depth int) uint64 {
+	position := g.currentPosition
+	return position.perft(depth, newPerftBuffers(depth))
+}
+
+// PerftDivide returns the perft of each legal movement of the current
+// position, with depth-1, keyed by the movement in Pure algebraic notation.
+// The sum of all values is Perft(depth).
+//
+// If depth is lower than 1, it will return an empty map.
+//
+// Example:
+//
+//	game, _ := NewGame("")
+//	game.PerftDivide(2) // returns map[a2a3:20 a2a4:20 b1a3:20 ...]
+func (g *Game) PerftDivide(depth int) map[string]uint64 {
+	divide := make(map[string]uint64)
+	if depth < 1 {
+		return divide
+	}
+
+	position := g.currentPosition
+	buffers := newPerftBuffers(depth)
+
+	for _, movement := range position.legalMovements() {
+		undo := position.makeMovement(movement)
+		divide[movement.Algebraic()] = position.perft(depth-1, buffers)
+		position.unmakeMovement(movement, undo)
+	}
+
+	return divide
+}
+
+// PerftDetailed returns the detailed perft statistics of the current
+// position, up to the passed depth. It is slower than Perft(), as every
+// leaf node is classified.
+//
+// The game is not modified.
+//
+// Example:
+//
+//	game, _ := NewGame("")
+//	game.PerftDetailed(4) // returns PerftStats{Nodes: 197281, Captures: 1576, Checks: 469, Checkmates: 8, ...}
+func (g *Game) PerftDetailed(depth int) PerftStats {
+	var stats PerftStats
+
+	position := g.currentPosition
+	position.perftDetailed(depth, newPerftBuffers(depth), &stats)
+
+	return stats
+}
+
+// newPerftBuffers returns a movement buffer for each depth, up to the
+// passed depth.
+func newPerftBuffers(depth int) [][]Movement {
+	buffers := make([][]Movement, max(depth, 0)+1)
+	for i := range buffers {
+		buffers[i] = make([]Movement, 0, maxLegalMovements)
+	}
+	return buffers
+}
+
+// perft counts the leaf nodes of the movement tree up to the passed depth.
+// Movements are made and unmade in place, and generated into the buffer of
+// each depth, so no allocations are made.
+func (p *Position) perft(depth int, buffers [][]Movement) uint64 {
+	if depth <= 0 {
 		return 1
 	}
 
-	g.computeLegalMovements()
-	moveList := g.computedLegalMovements
-	nMoves = len(moveList)
+	movements := p.appendLegalMovements(buffers[depth][:0])
+	if depth == 1 {
+		return uint64(len(movements))
+	}
+
+	var nodes uint64
+	for _, movement := range movements {
+		undo := p.makeMovement(movement)
+		nodes += p.perft(depth-1, buffers)
+		p.unmakeMovement(movement, undo)
+	}
+
+	return nodes
+}
+
+func (p *Position) perftDetailed(depth int, buffers [][]Movement, stats *PerftStats) {
+	if depth <= 0 {
+		stats.Nodes++
+		return
+	}
+
+	for _, movement := range p.appendLegalMovements(buffers[depth][:0]) {
+		undo := p.makeMovement(movement)
+		if depth == 1 {
+			p.classifyPerftMovement(movement, buffers[0], stats)
+		} else {
+			p.perftDetailed(depth-1, buffers, stats)
+		}
+		p.unmakeMovement(movement, undo)
+	}
+}
+
+// classifyPerftMovement adds the leaf node reached by the movement to the
+// stats. It assumes that the movement has just been made in the position.
+func (p *Position) classifyPerftMovement(movement Movement, buffer []Movement, stats *PerftStats) {
+	stats.Nodes++
+
+	if movement.isTakingPiece {
+		stats.Captures++
+		if !movement.takingPiece.Square.IsEqualTo(movement.toSq) {
+			stats.EnPassants++
+		}
+	}
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		stats.Castles++
+	}
+	if movement.pawnPromotionTo != Kind_None {
+		stats.Promotions++
+	}
+
+	kings := p.pieceBitboards[p.playerToMove][Kind_King]
+	if kings == 0 {
+		return
+	}
+
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	checkers := p.attackersOf(kings.first(), movement.movingPiece.Color, occupied)
+	if checkers == 0 {
+		return
+	}
+
+	stats.Checks++
+
+	// The castling rook is also a moved piece
+	movedPieces := bitboardOf(squareIndex(movement.toSq))
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		_, rookToIndex := castlingRookIndexes(movement)
+		movedPieces |= bitboardOf(rookToIndex)
+	}
+	if checkers&movedPieces == 0 {
+		stats.DiscoveredChecks++
+	}
+	if checkers.count() > 1 {
+		stats.DoubleChecks++
+	}
+
+	if len(p.appendLegalMovements(buffer[:0])) == 0 {
+		stats.Checkmates++
+	}
+}
+
+// PerftOptions represents the configuration of PerftParallel().
+type PerftOptions struct {
+	// Workers is the amount of goroutines the root movements are split
+	// across. If it is lower than 1, runtime.GOMAXPROCS(0) will be used.
+	Workers int
+
+	// HashSize is the size, in megabytes, of the perft hash table shared
+	// by all the workers. If it is 0, no hash table is used.
+	HashSize int
+
+	// Progress, if set, is called each time a root movement is completed.
+	// It is called from the goroutine that called PerftParallel().
+	Progress func(PerftProgress)
+}
+
+// PerftProgress represents the progress of a PerftParallel() run.
+type PerftProgress struct {
+	Movement  string // Pure algebraic notation of the completed root movement
+	Nodes     uint64 // Nodes of the completed root movement
+	Completed int    // Root movements completed so far
+	Total     int    // Root movements in total
+}
+
+// PerftParallel returns the same result as Perft(), splitting the root
+// movements across multiple goroutines, each with its own copy of the
+// current position. Optionally, the workers share a hash table with the
+// nodes of the positions already explored.
+//
+// If the context is done before it finishes, it will return 0 and the
+// context's error.
+//
+// The game is not modified.
+//
+// Example:
+//
+//	game, _ := NewGame("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+//	game.PerftParallel(ctx, 5, PerftOptions{HashSize: 64}) // returns 193690690, nil
+func (g *Game) PerftParallel(ctx context.Context, depth int, options PerftOptions) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if depth < 2 {
+		return g.Perft(depth), nil
+	}
+
+	workers := options.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var table *perftTable
+	if options.HashSize > 0 {
+		table = newPerftTable(options.HashSize)
+	}
+
+	// Stops the workers once the context is done
+	var stop atomic.Bool
+	stopWatching := context.AfterFunc(ctx, func() {
+		stop.Store(true)
+	})
+	defer stopWatching()
 
-	for i = 0; i < nMoves; i++ {
-		g.simulateMovement(moveList[i])
-		nodes += g.perft(initialDepth, depth-1, moveList[i].Algebraic(), positionVerbose)
-		g.undoSimulatedMovement()
+	type perftResult struct {
+		movement  Movement
+		nodes     uint64
+		isAborted bool // Whether the subtree was cut short, so its nodes are not complete
 	}
 
-	if positionVerbose && depth == initialDepth-1 && currentMove != "" {
-		fmt.Printf("\t%s: %d\n", currentMove, nodes)
-		fmt.Printf("\t^ %s\n", g.currentPosition.Fen())
+	movements := g.currentPosition.legalMovements()
+	jobs := make(chan Movement, len(movements))
+	results := make(chan perftResult, len(movements))
+
+	for _, movement := range movements {
+		jobs <- movement
+	}
+	close(jobs)
+
+	for i := 0; i < min(workers, len(movements)); i++ {
+		go func(position Position) {
+			buffers := newPerftBuffers(depth)
+			for movement := range jobs {
+				isAborted := false
+				undo := position.makeMovement(movement)
+				nodes := position.perftHashed(depth-1, buffers, table, &stop, &isAborted)
+				position.unmakeMovement(movement, undo)
+
+				results <- perftResult{movement, nodes, isAborted}
+			}
+		}(g.currentPosition)
+	}
+
+	// The count is only discarded if a subtree was cut short, not if the
+	// context is done after every subtree was completed
+	var nodes uint64
+	isAborted := false
+	for completed := 1; completed <= len(movements); completed++ {
+		result := <-results
+		nodes += result.nodes
+		isAborted = isAborted || result.isAborted
+
+		if options.Progress != nil && !isAborted {
+			options.Progress(PerftProgress{
+				Movement:  result.movement.Algebraic(),
+				Nodes:     result.nodes,
+				Completed: completed,
+				Total:     len(movements),
+			})
+		}
+	}
+
+	if isAborted {
+		return 0, ctx.Err()
+	}
+
+	return nodes, nil
+}
+
+// perftHashed works as perft(), but looks up and stores the nodes of the
+// positions in the table, if any. It returns early once stop is set, setting
+// isAborted.
+func (p *Position) perftHashed(depth int, buffers [][]Movement, table *perftTable, stop *atomic.Bool, isAborted *bool) uint64 {
+	if depth <= 1 || (table == nil && depth == 2) {
+		return p.perft(depth, buffers)
+	}
+
+	if stop.Load() {
+		*isAborted = true
+		return 0
+	}
+
+	if table != nil {
+		if nodes, found := table.load(p.hash, depth); found {
+			return nodes
+		}
+	}
+
+	var nodes uint64
+	for _, movement := range p.appendLegalMovements(buffers[depth][:0]) {
+		undo := p.makeMovement(movement)
+		nodes += p.perftHashed(depth-1, buffers, table, stop, isAborted)
+		p.unmakeMovement(movement, undo)
+	}
+
+	if table != nil && !stop.Load() {
+		table.store(p.hash, depth, nodes)
 	}
 
 	return nodes
 }
+
+// perftTable is a hash table of perft results, keyed by position hash and
+// depth, that can be shared by multiple goroutines without locks.
+//
+// Each entry stores the data and its key XORed with the data, so an entry
+// torn by concurrent writes is detected, and ignored, when loaded.
+type perftTable struct {
+	entries []perftEntry
+	mask    uint64
+}
+
+type perftEntry struct {
+	check atomic.Uint64 // Key XOR data
+	data  atomic.Uint64 // Nodes in the upper 56 bits, depth in the lower 8 bits
+}
+
+// newPerftTable creates a table of the largest power of two amount of
+// entries that fits in the passed megabytes.
+func newPerftTable(megabytes int) *perftTable {
+	size := uint64(megabytes) << 20 / uint64(unsafe.Sizeof(perftEntry{}))
+	size = 1 << (bits.Len64(size) - 1)
+
+	return &perftTable{
+		entries: make([]perftEntry, size),
+		mask:    size - 1,
+	}
+}
+
+func (t *perftTable) load(hash uint64, depth int) (uint64, bool) {
+	entry := &t.entries[hash&t.mask]
+	check, data := entry.check.Load(), entry.data.Load()
+
+	if check^data != hash || data&0xFF != uint64(depth) {
+		return 0, false
+	}
+	return data >> 8, true
+}
+
+func (t *perftTable) store(hash uint64, depth int, nodes uint64) {
+	entry := &t.entries[hash&t.mask]
+	data := nodes<<8 | uint64(depth)
+
+	entry.check.Store(hash ^ data)
+	entry.data.Store(data)
+}
@@ -0,0 +1,113 @@
+package chess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPerftDetailed(t *testing.T) {
+	// Results from the chessprogramming reference tables. The discovered
+	// checks of the last position are not in them, and were checked by hand.
+	tests := []struct {
+		fen   string
+		depth int
+		stats PerftStats
+	}{
+		{
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			depth: 4,
+			stats: PerftStats{Nodes: 197281, Captures: 1576, Checks: 469, Checkmates: 8},
+		},
+		{
+			fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			depth: 3,
+			stats: PerftStats{Nodes: 97862, Captures: 17102, EnPassants: 45, Castles: 3162, Checks: 993, Checkmates: 1},
+		},
+		{
+			fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			depth: 5,
+			stats: PerftStats{Nodes: 674624, Captures: 52051, EnPassants: 1165, Checks: 52950, DiscoveredChecks: 1292, DoubleChecks: 3},
+		},
+		{
+			fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			depth: 3,
+			stats: PerftStats{Nodes: 9467, Captures: 1021, EnPassants: 4, Promotions: 120, Checks: 38, DiscoveredChecks: 2, Checkmates: 22},
+		},
+	}
+
+	for _, test := range tests {
+		game, _ := NewGame(test.fen)
+		if stats := game.PerftDetailed(test.depth); stats != test.stats {
+			t.Errorf("Expected perft %d of %q to be %+v, got %+v", test.depth, test.fen, test.stats, stats)
+		}
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	game, _ := NewGame("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	fen := game.CurrentFen()
+
+	divide := game.PerftDivide(3)
+	if len(divide) != 48 {
+		t.Fatalf("Expected 48 movements, got %d", len(divide))
+	}
+	if divide["e1g1"] != 2059 || divide["e2a6"] != 1907 {
+		t.Errorf("Expected e1g1: 2059 and e2a6: 1907, got e1g1: %d and e2a6: %d", divide["e1g1"], divide["e2a6"])
+	}
+
+	var total uint64
+	for _, nodes := range divide {
+		total += nodes
+	}
+	if total != game.Perft(3) || total != 97862 {
+		t.Errorf("Expected the divide to sum 97862, got %d", total)
+	}
+
+	if game.CurrentFen() != fen {
+		t.Errorf("Expected the game to remain unchanged, got %q", game.CurrentFen())
+	}
+}
+
+func TestPerftParallel(t *testing.T) {
+	game, _ := NewGame("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+
+	for _, options := range []PerftOptions{{Workers: 4}, {Workers: 4, HashSize: 16}, {HashSize: 1}} {
+		var progressNodes uint64
+		var completed int
+		options.Progress = func(progress PerftProgress) {
+			progressNodes += progress.Nodes
+			completed = progress.Completed
+			if progress.Total != 48 {
+				t.Errorf("Expected 48 root movements, got %d", progress.Total)
+			}
+		}
+
+		nodes, err := game.PerftParallel(context.Background(), 4, options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if nodes != 4085603 || progressNodes != nodes || completed != 48 {
+			t.Errorf("Expected 4085603 nodes in 48 movements with %+v, got %d (%d in progress) in %d", options, nodes, progressNodes, completed)
+		}
+	}
+
+	// A context done after the last subtree doesn't discard the count
+	ctx, cancel := context.WithCancel(context.Background())
+	nodes, err := game.PerftParallel(ctx, 4, PerftOptions{Workers: 2, Progress: func(progress PerftProgress) {
+		if progress.Completed == progress.Total {
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}})
+	if err != nil || nodes != 4085603 {
+		t.Errorf("Expected the complete perft to be kept, got %d nodes and error %v", nodes, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	nodes, err = game.PerftParallel(ctx, 5, PerftOptions{Workers: 2, Progress: func(PerftProgress) { cancel() }})
+	if !errors.Is(err, context.Canceled) || nodes != 0 {
+		t.Errorf("Expected the perft to be cancelled, got %d nodes and error %v", nodes, err)
+	}
+}
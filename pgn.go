@@ -0,0 +1,573 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The Seven Tag Roster, in the order they must be exported
+var pgnSevenTagRoster = [7]string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// The maximum line length of the exported PGN movetext
+const pgnMaxLineLength = 80
+
+// PGNGame represents a game parsed from a Portable Game Notation source.
+type PGNGame struct {
+	// Tags contains the tag pairs of the game, such as "Event", "White" or "FEN".
+	Tags map[string]string
+
+	// Game is the resulting game, after replaying all the movetext's
+	// main line movements from the starting position.
+	Game Game
+
+	// Comments contains the comments of the main line, keyed by the amount
+	// of movements made before the comment (0 is before the first movement).
+	Comments map[int][]string
+
+	// NAGs contains the Numeric Annotation Glyphs of the main line, keyed by
+	// the amount of movements made before the glyph (1 is after the first movement).
+	NAGs map[int][]int
+
+	// Variations contains the recursive variations of the main line, keyed by
+	// the amount of movements made before the variation's first movement (0 is
+	// an alternative to the first movement).
+	Variations map[int][]PGNVariation
+
+	// Result is the game termination marker: "1-0", "0-1", "1/2-1/2" or "*".
+	//
+	// If the movetext had no termination marker, it will be empty.
+	Result string
+}
+
+// PGNVariation represents a recursive variation of a PGN game: an
+// alternative sequence of movements to the one played.
+//
+// Its movements are not replayed, so they are not validated.
+type PGNVariation struct {
+	// Movements contains the movements of the variation, in Standard
+	// Algebraic Notation, as written in the movetext.
+	Movements []string
+
+	// Comments, NAGs and Variations are keyed by the amount of movements of
+	// the variation made before them, as in PGNGame.
+	Comments   map[int][]string
+	NAGs       map[int][]int
+	Variations map[int][]PGNVariation
+}
+
+func newPGNVariation() *PGNVariation {
+	return &PGNVariation{
+		Movements:  make([]string, 0),
+		Comments:   make(map[int][]string),
+		NAGs:       make(map[int][]int),
+		Variations: make(map[int][]PGNVariation),
+	}
+}
+
+// PGNReader reads games in Portable Game Notation from an io.Reader, one
+// game at a time, so big databases can be processed without loading them
+// completely in memory.
+type PGNReader struct {
+	reader   *bufio.Reader
+	drawMode DrawMode
+}
+
+// NewPGNReader returns a new PGNReader that reads from r.
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{
+		reader:   bufio.NewReader(r),
+		drawMode: DrawMode_Claim,
+	}
+}
+
+// SetDrawMode sets the draw mode of the games read, used once their
+// movements are replayed. By default, DrawMode_Claim is used.
+//
+// Note: The movements are always replayed with DrawMode_Claim, as recorded
+// games may continue after a draw that was not claimed.
+func (pr *PGNReader) SetDrawMode(mode DrawMode) {
+	pr.drawMode = mode
+}
+
+// ParsePGN reads and returns all the games of the PGN source.
+//
+// The games use DrawMode_Claim, unlike the ones created via NewGame(), as
+// recorded games may continue after a draw that was not claimed. Use
+// SetDrawMode() on the resulting games, or a PGNReader, to change it.
+//
+// If any game is invalid, it will return the games parsed so far and the error.
+func ParsePGN(r io.Reader) ([]PGNGame, error) {
+	pgnReader := NewPGNReader(r)
+	games := make([]PGNGame, 0)
+
+	for {
+		pgnGame, err := pgnReader.Next()
+		if err == io.EOF {
+			return games, nil
+		} else if err != nil {
+			return games, err
+		}
+
+		games = append(games, pgnGame)
+	}
+}
+
+// Next reads and returns the next game of the PGN source.
+//
+// Recursive variations are parsed, with their comments and NAGs, but not
+// replayed: only the main line is applied to the resulting Game.
+//
+// The resulting Game uses the reader's draw mode, DrawMode_Claim by
+// default. Check SetDrawMode() for more information.
+//
+// If there are no more games, it will return io.EOF. If the game is invalid,
+// it will return an empty PGNGame and the error, and the next call will
+// continue with the following game.
+func (pr *PGNReader) Next() (PGNGame, error) {
+	pgnGame := PGNGame{
+		Tags: make(map[string]string),
+	}
+
+	var game *Game
+	var gameErr error
+
+	startGame := func() {
+		if game != nil || gameErr != nil {
+			return
+		}
+
+		newGame, err := NewGame(pgnGame.Tags["FEN"])
+		if isChess960Variant(pgnGame.Tags["Variant"]) {
+			fen := pgnGame.Tags["FEN"]
+			if fen == "" {
+				fen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+			}
+			newGame, err = NewChess960GameFromFen(fen)
+		}
+		if err != nil {
+			gameErr = fmt.Errorf("The PGN game has an invalid FEN tag: %w", err)
+			return
+		}
+		// Recorded games may continue after a draw that was not claimed
+		newGame.SetDrawMode(DrawMode_Claim)
+		game = &newGame
+	}
+
+	isEmpty := true
+	hasMovetext := false
+
+	// The main line, followed by the variations being read, each one nested
+	// in the previous one. The movements of each variation branch from
+	// branchPlies movements of its parent line.
+	mainLine := newPGNVariation()
+	lines := []*PGNVariation{mainLine}
+	branchPlies := []int{0}
+
+	for {
+		r, err := pr.skipWhitespace()
+		if err == io.EOF {
+			if isEmpty {
+				return PGNGame{}, io.EOF
+			}
+			break
+		} else if err != nil {
+			return PGNGame{}, err
+		}
+
+		isEmpty = false
+
+		if r == '[' && hasMovetext {
+			// A new game's tag section started without a termination marker
+			pr.reader.UnreadRune()
+			break
+		}
+
+		line := lines[len(lines)-1]
+		plyCount := len(line.Movements)
+
+		switch r {
+		case '[':
+			name, value, err := pr.readTag()
+			if err != nil {
+				return PGNGame{}, err
+			}
+			pgnGame.Tags[name] = value
+			continue
+		case '%':
+			pr.readUntil('\n')
+			continue
+		case ';':
+			comment, _ := pr.readUntil('\n')
+			line.Comments[plyCount] = append(line.Comments[plyCount], strings.TrimSpace(comment))
+			continue
+		}
+
+		hasMovetext = true
+
+		switch r {
+		case '{':
+			comment, err := pr.readUntil('}')
+			if err != nil {
+				return PGNGame{}, errors.New("The PGN game has a comment that is never closed.")
+			}
+			line.Comments[plyCount] = append(line.Comments[plyCount], strings.TrimSpace(comment))
+		case '(':
+			// A variation is an alternative to the last movement
+			lines = append(lines, newPGNVariation())
+			branchPlies = append(branchPlies, max(plyCount-1, 0))
+		case ')':
+			if len(lines) == 1 {
+				gameErr = errors.New("The PGN game closes a variation that was never opened.")
+				break
+			}
+
+			parent, branch := lines[len(lines)-2], branchPlies[len(lines)-1]
+			parent.Variations[branch] = append(parent.Variations[branch], *line)
+			lines, branchPlies = lines[:len(lines)-1], branchPlies[:len(lines)-1]
+		case '$':
+			nag, _ := strconv.Atoi(pr.readSymbol())
+			line.NAGs[plyCount] = append(line.NAGs[plyCount], nag)
+		default:
+			token := string(r) + pr.readSymbol()
+
+			if isPGNResult(token) {
+				if len(lines) != 1 {
+					gameErr = errors.New("The PGN game ends inside of a variation.")
+				}
+				pgnGame.Result = token
+				break
+			}
+
+			san := strings.TrimLeft(token, "0123456789")
+			if strings.HasPrefix(san, ".") || san == "" {
+				// Movement number indication, optionally followed by the movement
+				san = strings.TrimLeft(san, ".")
+			} else {
+				// Castling with zeroes (0-0), or a regular movement
+				san = token
+			}
+
+			// En passant captures may be suffixed with "e.p.", as in "exd6 e.p."
+			san = strings.TrimSuffix(san, "e.p.")
+
+			if san == "" || strings.Trim(san, "!?") == "" {
+				continue
+			}
+
+			line.Movements = append(line.Movements, san)
+			if line != mainLine {
+				continue
+			}
+
+			startGame()
+			if gameErr == nil {
+				if err := game.MakeMovementSAN(san); err != nil {
+					gameErr = fmt.Errorf("The PGN game has an invalid movement after %d movements: %w", len(game.movementHistory), err)
+				}
+			}
+			continue
+		}
+
+		if pgnGame.Result != "" {
+			break
+		}
+	}
+
+	if gameErr == nil && len(lines) != 1 {
+		gameErr = errors.New("The PGN game has a variation that is never closed.")
+	}
+
+	startGame()
+	if gameErr != nil {
+		return PGNGame{}, gameErr
+	}
+
+	pgnGame.Comments = mainLine.Comments
+	pgnGame.NAGs = mainLine.NAGs
+	pgnGame.Variations = mainLine.Variations
+
+	for name, value := range pgnGame.Tags {
+		game.tags[name] = value
+	}
+
+	if game.outcome == Outcome_None {
+		game.Terminate(pgnResultOutcome(game, pgnGame.Result, pgnGame.Tags["Termination"]))
+	}
+
+	game.SetDrawMode(pr.drawMode)
+	pgnGame.Game = *game
+	return pgnGame, nil
+}
+
+func isPGNResult(token string) bool {
+	return token == "1-0" || token == "0-1" || token == "1/2-1/2" || token == "*"
+}
+
+func (pr *PGNReader) skipWhitespace() (rune, error) {
+	for {
+		r, _, err := pr.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(r) {
+			return r, nil
+		}
+	}
+}
+
+// readUntil reads until the delimiter is found, and returns the text
+// before it. The delimiter is consumed.
+func (pr *PGNReader) readUntil(delimiter rune) (string, error) {
+	var sb strings.Builder
+	for {
+		r, _, err := pr.reader.ReadRune()
+		if err != nil {
+			return sb.String(), err
+		}
+		if r == delimiter {
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// readSymbol reads until a whitespace or a PGN delimiter is found. The
+// delimiter is not consumed.
+func (pr *PGNReader) readSymbol() string {
+	var sb strings.Builder
+	for {
+		r, _, err := pr.reader.ReadRune()
+		if err != nil {
+			return sb.String()
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune("[]{}();$%\"", r) {
+			pr.reader.UnreadRune()
+			return sb.String()
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// readTag reads a tag pair, assuming the opening bracket was already read.
+func (pr *PGNReader) readTag() (string, string, error) {
+	r, err := pr.skipWhitespace()
+	if err != nil {
+		return "", "", errors.New("The PGN game has an unfinished tag pair.")
+	}
+	pr.reader.UnreadRune()
+
+	name := pr.readSymbol()
+	if name == "" || r == '"' {
+		return "", "", errors.New("The PGN game has a tag pair without name.")
+	}
+
+	if r, err := pr.skipWhitespace(); err != nil || r != '"' {
+		return "", "", fmt.Errorf("The PGN game's tag pair \"%s\" does not have a valid value.", name)
+	}
+
+	var sb strings.Builder
+	for {
+		r, _, err := pr.reader.ReadRune()
+		if err != nil {
+			return "", "", fmt.Errorf("The PGN game's tag pair \"%s\" does not have a valid value.", name)
+		}
+
+		if r == '\\' {
+			escaped, _, err := pr.reader.ReadRune()
+			if err != nil {
+				return "", "", fmt.Errorf("The PGN game's tag pair \"%s\" does not have a valid value.", name)
+			}
+			sb.WriteRune(escaped)
+			continue
+		}
+
+		if r == '"' {
+			break
+		}
+		sb.WriteRune(r)
+	}
+
+	if r, err := pr.skipWhitespace(); err != nil || r != ']' {
+		return "", "", fmt.Errorf("The PGN game's tag pair \"%s\" is not closed.", name)
+	}
+
+	return name, sb.String(), nil
+}
+
+// Tag returns the value of the game's PGN tag pair with the given name.
+//
+// If the tag is not set, it will return an empty string.
+func (g *Game) Tag(name string) string {
+	return g.tags[name]
+}
+
+// SetTag sets the value of the game's PGN tag pair with the given name, which
+// will be used when exporting the game in Portable Game Notation.
+//
+// Note: The "Result", "SetUp" and "FEN" tags are always derived from the game
+// when exporting it.
+func (g *Game) SetTag(name, value string) {
+	g.tags[name] = value
+}
+
+// PGN returns the game in Portable Game Notation, as an string.
+//
+// It contains the Seven Tag Roster (unknown values are exported as "?"),
+// the SetUp and FEN tags if the game did not start from the standard
+// position, the Variant tag in Chess960 games (unless set via SetTag), any
+// other tag set via SetTag, and the movetext in SAN.
+func (g *Game) PGN() string {
+	var sb strings.Builder
+	g.WritePGN(&sb)
+	return sb.String()
+}
+
+// WritePGN writes the game in Portable Game Notation to w.
+//
+// Check PGN() for more information about the output.
+func (g *Game) WritePGN(w io.Writer) error {
+	var sb strings.Builder
+
+	result := g.outcome.PGNResult()
+
+	for _, name := range pgnSevenTagRoster {
+		value, ok := g.tags[name]
+		if name == "Result" {
+			value = result
+		} else if !ok || value == "" {
+			value = "?"
+			if name == "Date" {
+				value = "????.??.??"
+			}
+		}
+		writePGNTag(&sb, name, value)
+	}
+
+	startingFen := g.StartingFen()
+	if startingFen != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		writePGNTag(&sb, "SetUp", "1")
+		writePGNTag(&sb, "FEN", startingFen)
+	}
+
+	tags := g.tags
+	if _, ok := tags["Variant"]; !ok && g.currentPosition.isChess960 {
+		tags = maps.Clone(g.tags)
+		tags["Variant"] = "Chess960"
+	}
+
+	otherTags := make([]string, 0, len(tags))
+	for name := range tags {
+		if !slices.Contains(pgnSevenTagRoster[:], name) && name != "SetUp" && name != "FEN" {
+			otherTags = append(otherTags, name)
+		}
+	}
+	slices.Sort(otherTags)
+	for _, name := range otherTags {
+		writePGNTag(&sb, name, tags[name])
+	}
+
+	sb.WriteRune('\n')
+
+	startingPosition, _ := g.PositionAtIndex(0)
+	fullmoveCounter := startingPosition.fullmoveCounter
+	turn := startingPosition.playerToMove
+
+	tokens := make([]string, 0, len(g.movementHistory)*2)
+	for i, san := range g.MovementHistorySAN() {
+		if turn == Color_White {
+			tokens = append(tokens, strconv.Itoa(int(fullmoveCounter))+". "+san)
+		} else {
+			if i == 0 {
+				tokens = append(tokens, strconv.Itoa(int(fullmoveCounter))+"... "+san)
+			} else {
+				tokens = append(tokens, san)
+			}
+			fullmoveCounter++
+		}
+		turn = turn.Opposite()
+	}
+	tokens = append(tokens, result)
+
+	lineLength := 0
+	for _, token := range tokens {
+		if lineLength > 0 {
+			if lineLength+1+len(token) > pgnMaxLineLength {
+				sb.WriteRune('\n')
+				lineLength = 0
+			} else {
+				sb.WriteRune(' ')
+				lineLength++
+			}
+		}
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+	sb.WriteString("\n\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writePGNTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+	sb.WriteString("[" + name + " \"" + value + "\"]\n")
+}
+
+// pgnResultOutcome returns the outcome of a game that did not end on the
+// board, based on its result and termination tag.
+//
+// Only the methods stated by the termination tag are used, as in "time
+// forfeit", "adjudication" or "Black won by resignation". Draws that can be
+// claimed on the board are used for "normal" or missing terminations.
+// Otherwise, the method is unknown.
+func pgnResultOutcome(game *Game, result, termination string) Outcome {
+	var winner Color
+	switch result {
+	case "1-0":
+		winner = Color_White
+	case "0-1":
+		winner = Color_Black
+	case "1/2-1/2":
+		winner = Color_None
+	default:
+		return Outcome_None
+	}
+
+	termination = strings.ToLower(termination)
+	switch {
+	case strings.Contains(termination, "time forfeit") || strings.Contains(termination, "on time"):
+		if winner == Color_None {
+			return Outcome_Draw_Timeout
+		}
+		return NewOutcome(Method_Timeout, winner)
+	case strings.Contains(termination, "resign") && winner != Color_None:
+		return NewOutcome(Method_Resignation, winner)
+	case strings.Contains(termination, "agree") && winner == Color_None:
+		return Outcome_Draw_Agreement
+	case termination == "adjudication":
+		return NewOutcome(Method_Adjudication, winner)
+	}
+
+	if winner == Color_None && (termination == "" || termination == "normal") {
+		if claimableDraws := game.CanClaimDraw(); len(claimableDraws) > 0 {
+			return claimableDraws[0]
+		}
+	}
+
+	return NewOutcome(Method_Unknown, winner)
+}
+
+// isChess960Variant reports whether the PGN "Variant" tag value refers
+// to Chess960, such as "Chess960" or "Fischerandom".
+func isChess960Variant(variant string) bool {
+	variant = strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(variant))
+	return variant == "chess960" || variant == "fischerandom" || variant == "fischerrandom" || variant == "960"
+}
@@ -0,0 +1,215 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPGN = `[Event "Casual game"]
+[Site "?"]
+[White "Anderssen, Adolf"]
+[Black "Kieseritzky, Lionel"]
+[Result "1-0"]
+
+1. e4 e5 2. f4 exf4 3. Bc4 Qh4+ 4. Kf1 b5?! 5. Bxb5 Nf6 6. Nf3 Qh6 7. d3 Nh5
+8. Nh4 Qg5 9. Nf5 c6 10. g4 Nf6 11. Rg1 cxb5 12. h4 Qg6 13. h5 Qg5 14. Qf3 Ng8
+15. Bxf4 Qf6 16. Nc3 Bc5 17. Nd5 Qxb2 18. Bd6 Bxg1 {It is a queen sacrifice.}
+19. e5 Qxa1+ 20. Ke2 Na6 21. Nxg7+ Kd8 22. Qf6+ $1 Nxf6 23. Be7# 1-0
+
+[Event "Scholar's mate"]
+[Result "1-0"]
+
+1.e4 e5 2.Qh5 (2.Bc4 {Slower.} Nc6 (2...Nf6 $1 3.d3) 3.Qh5) 2...Nc6 3.Bc4 Nf6 $4 ; A blunder
+4.Qxf7# 1-0
+
+[Event "Endgame"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1"]
+[Result "*"]
+
+1. O-O Kd7 2. Rf7+ *
+`
+
+func TestPGNReader(t *testing.T) {
+	games, err := ParsePGN(strings.NewReader(testPGN))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing PGN: %s", err)
+	}
+
+	if len(games) != 3 {
+		t.Fatalf("Expected 3 games, got %d", len(games))
+	}
+
+	immortal := games[0]
+	if immortal.Tags["White"] != "Anderssen, Adolf" || immortal.Result != "1-0" {
+		t.Errorf("Unexpected tags or result: %v, %q", immortal.Tags, immortal.Result)
+	}
+	if len(immortal.Game.MovementHistory()) != 45 {
+		t.Errorf("Expected 45 movements, got %d", len(immortal.Game.MovementHistory()))
+	}
+	if immortal.Game.Outcome() != Outcome_Checkmate_White {
+		t.Errorf("Expected the game to end in checkmate, got %s", immortal.Game.Outcome())
+	}
+	if len(immortal.Comments[36]) != 1 || immortal.Comments[36][0] != "It is a queen sacrifice." {
+		t.Errorf("Unexpected comments: %v", immortal.Comments)
+	}
+	if len(immortal.NAGs[43]) != 1 || immortal.NAGs[43][0] != 1 {
+		t.Errorf("Unexpected NAGs: %v", immortal.NAGs)
+	}
+
+	scholar := games[1]
+	if got := strings.Join(scholar.Game.MovementHistorySAN(), " "); got != "e4 e5 Qh5 Nc6 Bc4 Nf6 Qxf7#" {
+		t.Errorf("Unexpected main line: %s", got)
+	}
+	if len(scholar.Variations[2]) != 1 {
+		t.Fatalf("Expected a variation for the third movement, got %v", scholar.Variations)
+	}
+	variation := scholar.Variations[2][0]
+	if got := strings.Join(variation.Movements, " "); got != "Bc4 Nc6 Qh5" {
+		t.Errorf("Unexpected variation: %s", got)
+	}
+	if len(variation.Comments[1]) != 1 || variation.Comments[1][0] != "Slower." {
+		t.Errorf("Unexpected variation comments: %v", variation.Comments)
+	}
+	if len(variation.Variations[1]) != 1 {
+		t.Fatalf("Expected a nested variation for the second movement, got %v", variation.Variations)
+	}
+	nested := variation.Variations[1][0]
+	if got := strings.Join(nested.Movements, " "); got != "Nf6 d3" || len(nested.NAGs[1]) != 1 || nested.NAGs[1][0] != 1 {
+		t.Errorf("Unexpected nested variation: %s, %v", got, nested.NAGs)
+	}
+
+	endgame := games[2]
+	if endgame.Result != "*" || endgame.Game.CurrentFen() != "8/3k1R2/8/8/8/8/8/R5K1 b - - 3 2" {
+		t.Errorf("Unexpected position after setup: %s", endgame.Game.CurrentFen())
+	}
+}
+
+func TestPGNReaderInvalidMovement(t *testing.T) {
+	pgnReader := NewPGNReader(strings.NewReader("1. e4 e5 2. Ke3 *\n\n1. d4 *"))
+
+	if _, err := pgnReader.Next(); err == nil {
+		t.Errorf("Expected an error for an illegal movement")
+	}
+
+	pgnGame, err := pgnReader.Next()
+	if err != nil || len(pgnGame.Game.MovementHistory()) != 1 {
+		t.Errorf("Expected the reader to continue with the next game, got %v", err)
+	}
+}
+
+func TestPGNReaderEnPassantSuffix(t *testing.T) {
+	pgnReader := NewPGNReader(strings.NewReader("1. e4 Nf6 2. e5 d5 3. exd6 e.p. (3. exd6e.p. exd6) 3... Nc6 *"))
+
+	pgnGame, err := pgnReader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing PGN: %s", err)
+	}
+	if got := strings.Join(pgnGame.Game.MovementHistorySAN(), " "); got != "e4 Nf6 e5 d5 exd6 Nc6" {
+		t.Errorf("Unexpected main line: %s", got)
+	}
+	if len(pgnGame.Variations[4]) != 1 || strings.Join(pgnGame.Variations[4][0].Movements, " ") != "exd6 exd6" {
+		t.Errorf("Unexpected variations: %v", pgnGame.Variations)
+	}
+}
+
+func TestPGNReaderUnclosedVariation(t *testing.T) {
+	pgnReader := NewPGNReader(strings.NewReader("1. e4 (1. d4 d5"))
+
+	if _, err := pgnReader.Next(); err == nil {
+		t.Errorf("Expected an error for a variation that is never closed")
+	}
+}
+
+func TestWritePGN(t *testing.T) {
+	game, _ := NewGame("")
+	game.SetTag("White", "Legall")
+	game.SetTag("Annotator", "\"Anonymous\"")
+	for _, san := range []string{"e4", "e5", "Nf3", "d6", "Bc4", "Bg4", "Nc3", "g6", "Nxe5", "Bxd1", "Bxf7+", "Ke7", "Nd5#"} {
+		if err := game.MakeMovementSAN(san); err != nil {
+			t.Fatalf("Unexpected error making %s: %s", san, err)
+		}
+	}
+
+	expected := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Legall"]
+[Black "?"]
+[Result "1-0"]
+[Annotator "\"Anonymous\""]
+
+1. e4 e5 2. Nf3 d6 3. Bc4 Bg4 4. Nc3 g6 5. Nxe5 Bxd1 6. Bxf7+ Ke7 7. Nd5# 1-0
+
+`
+	if got := game.PGN(); got != expected {
+		t.Errorf("Unexpected PGN:\n%s", got)
+	}
+
+	setUpGame, _ := NewGame("4k3/8/8/8/8/8/8/R3K2R b KQ - 5 40")
+	for i := 0; i < 30; i++ {
+		setUpGame.MakeMovement(setUpGame.LegalMovements()[0])
+		if setUpGame.Outcome() != Outcome_None {
+			break
+		}
+	}
+
+	games, err := ParsePGN(strings.NewReader(setUpGame.PGN()))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("Could not parse the exported PGN: %v", err)
+	}
+	if games[0].Game.CurrentFen() != setUpGame.CurrentFen() || games[0].Tags["SetUp"] != "1" {
+		t.Errorf("Exported PGN does not replay the same game:\n%s", setUpGame.PGN())
+	}
+	for _, line := range strings.Split(setUpGame.PGN(), "\n") {
+		if len(line) > 80 {
+			t.Errorf("Line exceeds 80 columns: %q", line)
+		}
+	}
+}
+
+func TestPGNReaderOutcome(t *testing.T) {
+	tests := []struct {
+		pgn     string
+		outcome Outcome
+	}{
+		{"1. e4 e5 1-0", Outcome_Unknown_White},
+		{"[Termination \"normal\"]\n\n1. e4 e5 0-1", Outcome_Unknown_Black},
+		{"[Termination \"abandoned\"]\n\n1. e4 e5 1-0", Outcome_Unknown_White},
+		{"[Termination \"adjudication\"]\n\n1. e4 e5 0-1", Outcome_Adjudication_Black},
+		{"[Termination \"White won by resignation\"]\n\n1. e4 e5 1-0", Outcome_Resignation_White},
+		{"[Termination \"time forfeit\"]\n\n1. e4 e5 0-1", Outcome_Timeout_Black},
+		{"1. e4 e5 1/2-1/2", Outcome_Draw_Unknown},
+		{"[Termination \"Adjudication\"]\n\n1. e4 e5 1/2-1/2", Outcome_Draw_Adjudication},
+		{"[Termination \"Game drawn by agreement\"]\n\n1. e4 e5 1/2-1/2", Outcome_Draw_Agreement},
+		{"1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 1/2-1/2", Outcome_Draw_3Rep},
+		{"1. e4 e5 *", Outcome_None},
+	}
+
+	for _, test := range tests {
+		games, err := ParsePGN(strings.NewReader(test.pgn))
+		if err != nil || len(games) != 1 {
+			t.Fatalf("Could not parse %q: %v", test.pgn, err)
+		}
+		if games[0].Game.Outcome() != test.outcome {
+			t.Errorf("Expected %q to end with %s, got %s", test.pgn, test.outcome, games[0].Game.Outcome())
+		}
+	}
+}
+
+func TestPGNReaderDrawMode(t *testing.T) {
+	pgn := "1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 5. e4 *"
+
+	games, err := ParsePGN(strings.NewReader(pgn))
+	if err != nil || games[0].Game.DrawMode() != DrawMode_Claim || games[0].Game.Outcome() != Outcome_None {
+		t.Fatalf("Expected the game to continue after the unclaimed repetition, got %v", err)
+	}
+
+	reader := NewPGNReader(strings.NewReader(pgn))
+	reader.SetDrawMode(DrawMode_Automatic)
+	pgnGame, err := reader.Next()
+	if err != nil || pgnGame.Game.DrawMode() != DrawMode_Automatic || len(pgnGame.Game.MovementHistory()) != 9 {
+		t.Errorf("Expected the game to be replayed, and use the reader's draw mode, got %v", err)
+	}
+}
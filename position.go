@@ -2,6 +2,7 @@ package chess
 
 import (
 	"errors"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -13,25 +14,41 @@ type Position struct {
 
 	playerToMove    Color
 	castlingRights  CastlingRights
-	enPassantSq     *Square
+	enPassantSq     Square
+	hasEnPassant    bool
 	halfmoveClock   uint8
 	fullmoveCounter uint
 	isChecked       bool
+	isChess960      bool
+
+	// Bitboards of the board's pieces, indexed by Color and Kind. The board
+	// is kept in sync with them, as the view returned by Board().
+	pieceBitboards [COLOR_AMOUNT + 1][KIND_AMOUNT + 1]bitboard
+	colorBitboards [COLOR_AMOUNT + 1]bitboard
+
+	hash uint64 // Polyglot Zobrist hash, updated incrementally
 
 	captures []Piece // Only used via API. Perft ignores this.
 }
 
-// Note: enPassantSq is not cloned, as it's not needed.
+// Note: The en passant square is not cloned, as it's not needed.
 func (p *Position) clone() Position {
 	return Position{
 		board: p.board,
 
 		playerToMove:    p.playerToMove,
-		castlingRights:  p.castlingRights.clone(),
-		enPassantSq:     nil,
+		castlingRights:  p.castlingRights,
+		hasEnPassant:    false,
 		halfmoveClock:   p.halfmoveClock,
 		fullmoveCounter: p.fullmoveCounter,
 		isChecked:       false,
+		isChess960:      p.isChess960,
+
+		pieceBitboards: p.pieceBitboards,
+		colorBitboards: p.colorBitboards,
+
+		// The en passant square is not cloned, so its key is removed
+		hash: p.hash ^ p.enPassantKey(),
 
 		captures: p.captures,
 	}
@@ -40,21 +57,12 @@ func (p *Position) clone() Position {
 // CastlingRights represents the position's current castling rights,
 // of both players.
 type CastlingRights struct {
-	queenSide map[Color]bool
-	kingSide  map[Color]bool
-}
+	queenSide [COLOR_AMOUNT + 1]bool
+	kingSide  [COLOR_AMOUNT + 1]bool
 
-func (cr *CastlingRights) clone() CastlingRights {
-	return CastlingRights{
-		queenSide: map[Color]bool{
-			Color_White: cr.queenSide[Color_White],
-			Color_Black: cr.queenSide[Color_Black],
-		},
-		kingSide: map[Color]bool{
-			Color_White: cr.kingSide[Color_White],
-			Color_Black: cr.kingSide[Color_Black],
-		},
-	}
+	// Files of the castling rooks. Other than a and h only in Chess960.
+	queenSideRookFile [COLOR_AMOUNT + 1]uint8
+	kingSideRookFile  [COLOR_AMOUNT + 1]uint8
 }
 
 // CastlingRights returns the position's current castling rights,
@@ -94,7 +102,7 @@ func (p Position) Captures() []Piece {
 // HasActiveEnPassant reports whether this possition has an active
 // en passsant oportunity.
 func (p Position) HasActiveEnPassant() bool {
-	return p.enPassantSq != nil
+	return p.hasEnPassant
 }
 
 // EnPassantSquare returns the current en passant square.
@@ -102,10 +110,21 @@ func (p Position) HasActiveEnPassant() bool {
 // If there is no en passant in the current position, it will
 // return an empty Square and the error.
 func (p Position) EnPassantSquare() (Square, error) {
-	if p.enPassantSq == nil {
+	if !p.hasEnPassant {
 		return Square{}, errors.New("The current position does not have an active en passant.")
 	}
-	return *p.enPassantSq, nil
+	return p.enPassantSq, nil
+}
+
+// Hash returns the 64-bit Zobrist hash of the position, computed with the
+// Polyglot opening book keys. Positions with the same piece placement,
+// turn, castling rights and capturable en passant have the same hash.
+//
+// Example:
+//
+//	NewGame("") // CurrentPosition().Hash() returns 0x463B96181691FC9C
+func (p Position) Hash() uint64 {
+	return p.hash
 }
 
 // Turn returns the position's player/side to move.
@@ -124,14 +143,95 @@ func (p Position) IsChecked() bool {
 	return p.isChecked
 }
 
+// HasInsufficientMaterial reports whether the passed color (player/side) can not
+// checkmate the opponent by any sequence of movements, as with a lone king, a
+// king and a knight against a king (or a king and queens), or kings and bishops
+// all placed on the same square color.
+//
+// Opponent's pawns, knights and rooks may block their own king, so they are
+// considered able to help in a checkmate. Opponent's queens are not: a queen
+// blocking the king always attacks the lone knight or bishop that gives check
+// (or can interpose), so such a checkmate can never happen.
+//
+// If both colors have insufficient material, the position is dead. If only one
+// of them does, it can be used to adjudicate a timeout of its opponent as a draw.
+func (p Position) HasInsufficientMaterial(color Color) bool {
+	allyPieces, allyKnights, allyBishops := 0, 0, 0
+	opponentBlockers, opponentBishops := 0, 0
+	bishopOnSquareColor := [2]bool{false, false}
+
+	for _, row := range p.board {
+		for _, piece := range row {
+			if piece.Kind == Kind_None {
+				continue
+			}
+
+			if piece.Kind == Kind_Bishop {
+				bishopOnSquareColor[(piece.Square.I+piece.Square.J)%2] = true
+			}
+
+			if piece.Color == color {
+				switch piece.Kind {
+				case Kind_Pawn, Kind_Rook, Kind_Queen:
+					return false
+				case Kind_Knight:
+					allyKnights++
+				case Kind_Bishop:
+					allyBishops++
+				}
+				allyPieces++
+			} else {
+				switch piece.Kind {
+				case Kind_Pawn, Kind_Rook, Kind_Knight:
+					opponentBlockers++
+				case Kind_Bishop:
+					opponentBishops++
+				}
+			}
+		}
+	}
+
+	if allyKnights > 0 {
+		// A lone knight can only mate if the opponent has pieces that block its own king
+		return allyPieces <= 2 && opponentBlockers == 0 && opponentBishops == 0
+	}
+
+	if allyBishops > 0 {
+		// Bishops can only mate if there are bishops on both square colors, or pieces that block a king
+		sameSquareColor := !bishopOnSquareColor[0] || !bishopOnSquareColor[1]
+		return sameSquareColor && opponentBlockers == 0
+	}
+
+	return true
+}
+
 // Fen returns the position's Forsyth–Edwards Notation, as an string, containing
 // the board piece placement, player to move, castling rights, en passant, halfmove clock
 // and fullmove counter.
 //
+// The castling rights are written in X-FEN, which is the same as standard
+// FEN, except in Chess960 positions where a castling rook is not the
+// outermost one, which is written with its file letter.
+//
 // For example, for a starting chess position:
 //
 //	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 func (p Position) Fen() string {
+	return p.fen(false)
+}
+
+// ShredderFen returns the position's Forsyth–Edwards Notation as Fen(), but
+// with the castling rights in Shredder-FEN, where each castling right is
+// written with the file letter of its rook.
+//
+// For example, for a starting chess position:
+//
+//	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"
+func (p Position) ShredderFen() string {
+	return p.fen(true)
+}
+
+func (p Position) fen(shredder bool) string {
 	var sb strings.Builder
 
 	sb.WriteString(p.board.Fen())
@@ -139,28 +239,11 @@ func (p Position) Fen() string {
 
 	sb.WriteRune(p.playerToMove.Rune())
 
-	if p.castlingRights.queenSide[Color_White] && p.castlingRights.kingSide[Color_White] && p.castlingRights.queenSide[Color_Black] && p.castlingRights.kingSide[Color_Black] {
-		sb.WriteRune(' ')
-
-		if p.castlingRights.kingSide[Color_White] {
-			sb.WriteRune('K')
-		}
-		if p.castlingRights.queenSide[Color_White] {
-			sb.WriteRune('Q')
-		}
-		if p.castlingRights.kingSide[Color_Black] {
-			sb.WriteRune('k')
-		}
-		if p.castlingRights.queenSide[Color_Black] {
-			sb.WriteRune('q')
-		}
-
-		sb.WriteRune(' ')
-	} else {
-		sb.WriteString(" - ")
-	}
+	sb.WriteRune(' ')
+	sb.WriteString(p.castlingFen(shredder))
+	sb.WriteRune(' ')
 
-	if p.enPassantSq != nil {
+	if p.hasEnPassant {
 		sb.WriteString(p.enPassantSq.Algebraic())
 	} else {
 		sb.WriteRune('-')
@@ -180,325 +263,461 @@ func newPositionFromFen(fen string) (Position, error) {
 		return Position{}, err
 	}
 
-	return Position{
+	position := Position{
 		board: newBoardFromFen(parsedFen.placementData),
 
 		playerToMove: parsedFen.activeColor,
 
-		castlingRights: CastlingRights{
-			queenSide: map[Color]bool{
-				Color_White: parsedFen.whiteCanQueenSideCastling,
-				Color_Black: parsedFen.blackCanQueenSideCastling,
-			},
-			kingSide: map[Color]bool{
-				Color_White: parsedFen.whiteCanKingSideCastling,
-				Color_Black: parsedFen.blackCanKingSideCastling,
-			},
-		},
-
-		enPassantSq:     parsedFen.enPassantSq,
 		halfmoveClock:   parsedFen.halfmoveClock,
 		fullmoveCounter: parsedFen.fulmoveCounter,
 
 		captures: make([]Piece, 0),
-	}, nil
-}
+	}
 
-func (p Position) computePseudoMovements(color Color, doCastlingCheck bool) ([]Movement, [8][8]bool) {
-	movements := make([]Movement, 0, 256)
-	var attackMatrix [8][8]bool
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			attackMatrix[i][j] = false
-		}
+	if parsedFen.enPassantSq != nil {
+		position.enPassantSq = *parsedFen.enPassantSq
+		position.hasEnPassant = true
 	}
 
-	for _, row := range p.board {
+	for _, row := range position.board {
 		for _, piece := range row {
-			if piece.Color == color {
-				p.computePiecePseudoMovements(piece, &movements, doCastlingCheck, &attackMatrix)
+			if piece.Kind != Kind_None {
+				position.setPiece(squareIndex(piece.Square), piece.Color, piece.Kind)
 			}
 		}
 	}
 
-	return movements, attackMatrix
+	// Castling rooks are found in the board
+	position.setCastlingFen(parsedFen.castling)
+	position.hash ^= position.stateKey()
+
+	return position, nil
 }
 
-func (p Position) computePiecePseudoMovements(piece Piece, movements *[]Movement, doCastlingCheck bool, attackMatrix *[8][8]bool) {
-	switch piece.Kind {
-	case Kind_Bishop:
-		p.computeDirectionPseudoMovements(piece, movements, attackMatrix, bishopDirections)
-		return
-	case Kind_Rook:
-		p.computeDirectionPseudoMovements(piece, movements, attackMatrix, rookDirections)
-		return
-	case Kind_Queen:
-		p.computeDirectionPseudoMovements(piece, movements, attackMatrix, bishopDirections)
-		p.computeDirectionPseudoMovements(piece, movements, attackMatrix, rookDirections)
-		return
-	case Kind_King:
-		for _, offset := range kingOffsets {
-			targetRow, targetCol := int8(piece.Square.I)+offset[0], int8(piece.Square.J)+offset[1]
-			if targetRow >= 0 && targetCol >= 0 && targetRow < 8 && targetCol < 8 {
-				row := uint8(targetRow)
-				col := uint8(targetCol)
-				pieceAt := p.board[row][col]
-
-				if pieceAt.Kind == Kind_None {
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						))
-				} else if pieceAt.Color != piece.Color {
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						).withTakingPiece(pieceAt))
-				}
-			}
+// LegalMovements returns a slice of legal movements of the position's turn.
+//
+// If no movements are legal, it will return an empty list.
+//
+// Example:
+//
+//	LegalMovements() // returns [Movement{...], Movement{...}, ...]
+//	LegalMovements() // returns []
+func (p Position) LegalMovements() []Movement {
+	return p.legalMovements()
+}
+
+// Apply returns the position resulting of making the passed movement in
+// this position. The position itself is not modified.
+//
+// Note: It assumes that the movement is legal in the position. Use
+// LegalMovements() to get the legal movements.
+func (p Position) Apply(movement Movement) Position {
+	newPosition := p.applyMovement(movement, true)
+	newPosition.isChecked = newPosition.isInCheck()
+	return newPosition
+}
+
+// IsCheckmate reports whether the position's turn is checkmated.
+func (p Position) IsCheckmate() bool {
+	return p.isInCheck() && len(p.legalMovements()) == 0
+}
 
+// IsStalemate reports whether the position's turn is stalemated.
+func (p Position) IsStalemate() bool {
+	return !p.isInCheck() && len(p.legalMovements()) == 0
+}
+
+func (p Position) legalMovements() []Movement {
+	return p.appendLegalMovements(make([]Movement, 0, 64))
+}
+
+// appendLegalMovements appends the legal movements of the position's turn
+// to the passed slice, and returns it. It does not allocate if the slice has
+// enough capacity.
+func (p *Position) appendLegalMovements(movements []Movement) []Movement {
+	allyPieces := p.colorBitboards[p.playerToMove]
+
+	for pieces := allyPieces; pieces != 0; pieces &= pieces - 1 {
+		index := pieces.first()
+		piece := p.board[index/8][index%8]
+		occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+
+		switch piece.Kind {
+		case Kind_Pawn:
+			p.appendPawnMovements(piece, &movements)
+		case Kind_Knight:
+			p.appendTargetMovements(piece, knightAttacks[index]&^allyPieces, &movements)
+		case Kind_Bishop:
+			p.appendTargetMovements(piece, bishopAttacks(index, occupied)&^allyPieces, &movements)
+		case Kind_Rook:
+			p.appendTargetMovements(piece, rookAttacks(index, occupied)&^allyPieces, &movements)
+		case Kind_Queen:
+			p.appendTargetMovements(piece, (bishopAttacks(index, occupied)|rookAttacks(index, occupied))&^allyPieces, &movements)
+		case Kind_King:
+			p.appendTargetMovements(piece, kingAttacks[index]&^allyPieces, &movements)
+			p.appendCastlingMovements(piece, &movements)
 		}
+	}
 
-		if doCastlingCheck {
-			castlingRow := 7
-			if piece.Color == Color_Black {
-				castlingRow = 0
-			}
+	return movements
+}
 
-			_, enemyAttackBoard := p.computePseudoMovements(piece.Color.Opposite(), false)
-			// If king is not in check, continue
-			if enemyAttackBoard[piece.Square.I][piece.Square.J] == false {
-				if p.castlingRights.queenSide[piece.Color] {
-					// Check if space to rook is empty
-					canCastle := true
-					for j := piece.Square.J - 1; j >= piece.Square.J-3; j-- {
-						if p.board[piece.Square.I][j].Kind != Kind_None {
-							canCastle = false
-							break
-						}
-					}
+// appendTargetMovements appends the legal movements of the piece to each
+// of the target squares, taking the opponent's piece if there is any.
+func (p *Position) appendTargetMovements(piece Piece, targets bitboard, movements *[]Movement) {
+	for ; targets != 0; targets &= targets - 1 {
+		target := squareFromIndex(targets.first())
+		movement := newMovement(piece, piece.Square, target)
 
-					if canCastle {
-						// Extra check: Position is not being attacked by enemy
-						// On queen side, positions that cannot be attacked to castle:
-						// On the left of the King, in d1 and c1 [castlingRow, 2], [castlingRow, 3]
-						if enemyAttackBoard[castlingRow][2] == false && enemyAttackBoard[castlingRow][3] == false {
-							*movements = append(*movements,
-								*newMovement(piece,
-									piece.Square,
-									newSquare(piece.Square.I, piece.Square.J-2),
-								).withCastling(true, false))
-						}
-					}
-				}
+		if pieceAt := p.board[target.I][target.J]; pieceAt.Kind != Kind_None {
+			movement.withTakingPiece(pieceAt)
+		}
 
-				if p.castlingRights.kingSide[piece.Color] {
-					// Check if space to rook is empty
-					canCastle := true
-					for j := piece.Square.J + 1; j < 7; j++ {
-						if p.board[piece.Square.I][j].Kind != Kind_None {
-							canCastle = false
-							break
-						}
-					}
+		p.appendIfLegal(*movement, movements)
+	}
+}
 
-					if canCastle {
-						// Extra check: Position is not being attacked by enemy
-						// On King side, positions that cannot be attacked to castle:
-						// On the right of the King, in f1 and g1 [castlingRow, 5], [castlingRow, 6]
-						if enemyAttackBoard[castlingRow][5] == false && enemyAttackBoard[castlingRow][6] == false {
-							*movements = append(*movements,
-								*newMovement(piece,
-									piece.Square,
-									newSquare(piece.Square.I, piece.Square.J+2),
-								).withCastling(false, true))
-						}
-					}
-				}
+func (p *Position) appendPawnMovements(piece Piece, movements *[]Movement) {
+	index := squareIndex(piece.Square)
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	promotionRow := pawnPromotionRows[piece.Color]
+
+	// Straight moves
+	targetRow := int8(piece.Square.I) + pawnMoveRowDirections[piece.Color]
+	if targetRow >= 0 && targetRow < 8 && !occupied.has(int(targetRow)*8+int(piece.Square.J)) {
+		target := newSquare(uint8(targetRow), piece.Square.J)
+
+		if target.I == promotionRow {
+			for _, kind := range promotableKinds {
+				p.appendIfLegal(*newMovement(piece, piece.Square, target).withPawn(false).withPawnPromotion(kind), movements)
+			}
+		} else {
+			p.appendIfLegal(*newMovement(piece, piece.Square, target).withPawn(false), movements)
+
+			doubleTargetRow := targetRow + pawnMoveRowDirections[piece.Color]
+			if piece.Square.I == pawnStartingRows[piece.Color] && !occupied.has(int(doubleTargetRow)*8+int(piece.Square.J)) {
+				doubleTarget := newSquare(uint8(doubleTargetRow), piece.Square.J)
+				p.appendIfLegal(*newMovement(piece, piece.Square, doubleTarget).withPawn(true), movements)
 			}
 		}
+	}
 
-		return
-	case Kind_Knight:
-		for _, offset := range knightOffsets {
-			targetRow := int8(piece.Square.I) + offset[0]
-			targetCol := int8(piece.Square.J) + offset[1]
-
-			if targetRow >= 0 && targetCol >= 0 && targetRow < 8 && targetCol < 8 {
-				row := uint8(targetRow)
-				col := uint8(targetCol)
-
-				pieceAt := p.board[row][col]
-
-				if pieceAt.Kind == Kind_None {
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						))
-				} else if pieceAt.Color != piece.Color {
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						).withTakingPiece(pieceAt))
-				}
+	// Diagonal moves
+	for targets := pawnAttacks[piece.Color][index] & p.colorBitboards[piece.Color.Opposite()]; targets != 0; targets &= targets - 1 {
+		target := squareFromIndex(targets.first())
+		pieceAt := p.board[target.I][target.J]
+
+		if target.I == promotionRow {
+			for _, kind := range promotableKinds {
+				p.appendIfLegal(*newMovement(piece, piece.Square, target).withTakingPiece(pieceAt).withPawn(false).withPawnPromotion(kind), movements)
 			}
+		} else {
+			p.appendIfLegal(*newMovement(piece, piece.Square, target).withTakingPiece(pieceAt).withPawn(false), movements)
 		}
+	}
+
+	// En passant, the taken pawn is next to the moving pawn
+	if p.hasEnPassant && pawnAttacks[piece.Color][index].has(squareIndex(p.enPassantSq)) {
+		pieceAt := p.board[piece.Square.I][p.enPassantSq.J]
+		p.appendIfLegal(*newMovement(piece, piece.Square, p.enPassantSq).withTakingPiece(pieceAt).withPawn(false), movements)
+	}
+}
 
+func (p *Position) appendCastlingMovements(piece Piece, movements *[]Movement) {
+	if !p.castlingRights.queenSide[piece.Color] && !p.castlingRights.kingSide[piece.Color] {
 		return
+	}
 
-	case Kind_Pawn:
-		// Straight moves
-		var maxDistance int8 = 2
-		if piece.Square.I != pawnStartingRows[piece.Color] {
-			maxDistance = 1
-		}
+	if piece.Square.I != castlingRows[piece.Color] {
+		return
+	}
 
-		promotionRow := uint8(0)
-		if piece.Color == Color_Black {
-			promotionRow = 7
-		}
+	if p.castlingRights.queenSide[piece.Color] {
+		p.appendCastlingMovement(piece, p.castlingRights.queenSideRookFile[piece.Color], true, movements)
+	}
 
-		for i := int8(1); i <= maxDistance; i++ {
-			targetRow := int8(piece.Square.I) + pawnMoveRowDirections[piece.Color]*i
-			if targetRow >= 0 && targetRow < 8 {
-				row := uint8(targetRow)
-				pieceAt := p.board[row][piece.Square.J]
-				if pieceAt.Kind != Kind_None {
-					break
-				}
+	if p.castlingRights.kingSide[piece.Color] {
+		p.appendCastlingMovement(piece, p.castlingRights.kingSideRookFile[piece.Color], false, movements)
+	}
+}
 
-				if row == promotionRow {
-					for _, kind := range promotableKinds {
-						*movements = append(*movements,
-							*newMovement(piece,
-								piece.Square,
-								newSquare(row, piece.Square.J),
-							).withPawn(i == 2).withPawnPromotion(kind))
-					}
-				} else {
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, piece.Square.J),
-						).withPawn(i == 2))
-				}
-			}
+// appendCastlingMovement appends the castling of the king with the rook at
+// the passed file, if it is legal. As in Chess960, the king always ends on the
+// c or g file, and the rook on the d or f file.
+func (p *Position) appendCastlingMovement(king Piece, rookFile uint8, isQueenSide bool, movements *[]Movement) {
+	row := int(king.Square.I)
+	kingFromIndex, rookFromIndex := row*8+int(king.Square.J), row*8+int(rookFile)
+	kingToIndex, rookToIndex := row*8+6, row*8+5
+	if isQueenSide {
+		kingToIndex, rookToIndex = row*8+2, row*8+3
+	}
+
+	if !p.pieceBitboards[king.Color][Kind_Rook].has(rookFromIndex) {
+		return
+	}
+
+	// Check if the squares the king and rook go through are empty, except for themselves
+	occupied := (p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]) &^ bitboardOf(kingFromIndex) &^ bitboardOf(rookFromIndex)
+	if occupied&(rowSpan(kingFromIndex, kingToIndex)|rowSpan(rookFromIndex, rookToIndex)) != 0 {
+		return
+	}
+
+	// The king can not be in check, nor pass through or end in an attacked square. The
+	// rook is removed, as it could be hiding an attack to the king's final square.
+	for squares := rowSpan(kingFromIndex, kingToIndex); squares != 0; squares &= squares - 1 {
+		if p.attackersOf(squares.first(), king.Color.Opposite(), occupied) != 0 {
+			return
 		}
+	}
 
-		// Diagonal move
-		for _, offset := range pawnAttackOffsets[piece.Color] {
-			targetRow := int8(piece.Square.I) + offset[0]
-			targetCol := int8(piece.Square.J) + offset[1]
-
-			if targetRow >= 0 && targetCol >= 0 && targetRow < 8 && targetCol < 8 {
-				row := uint8(targetRow)
-				col := uint8(targetCol)
-
-				pieceAt := p.board[row][col]
-
-				if pieceAt.Kind != Kind_None && pieceAt.Color != piece.Color {
-					if row == pawnPromotionRows[piece.Color] {
-						for _, kind := range promotableKinds {
-							attackMatrix[row][col] = true
-							*movements = append(*movements,
-								*newMovement(piece,
-									piece.Square,
-									newSquare(row, col),
-								).withTakingPiece(pieceAt).withPawn(false).withPawnPromotion(kind))
-						}
-					} else {
-						attackMatrix[row][col] = true
-						*movements = append(*movements,
-							*newMovement(piece,
-								piece.Square,
-								newSquare(row, col),
-							).withTakingPiece(pieceAt).withPawn(false))
-					}
-				} else {
-					// If there is no piece in the diagonal, we cannot move to it, but mark the position as being attacked
-					attackMatrix[row][col] = true
-				}
+	*movements = append(*movements, *newMovement(king,
+		king.Square,
+		squareFromIndex(kingToIndex),
+	).withCastling(isQueenSide, !isQueenSide, squareFromIndex(rookFromIndex), p.isChess960))
+}
 
-				// En passant available check in current diagonal
-				if pieceAt.Kind == Kind_None && p.enPassantSq != nil && p.enPassantSq.I == row && p.enPassantSq.J == col {
-					enPassantPieceSquare := newSquare(p.enPassantSq.I+1, p.enPassantSq.J)
-					if piece.Color == Color_Black {
-						enPassantPieceSquare.I = p.enPassantSq.I - 1
-					}
+// appendIfLegal appends the pseudo legal movement if it does not leave
+// its own king under check.
+func (p *Position) appendIfLegal(movement Movement, movements *[]Movement) {
+	color := movement.movingPiece.Color
+	fromBitboard := bitboardOf(squareIndex(movement.fromSq))
+	toBitboard := bitboardOf(squareIndex(movement.toSq))
 
-					pieceAt := p.board[enPassantPieceSquare.I][enPassantPieceSquare.J]
+	occupied := (p.colorBitboards[Color_White]|p.colorBitboards[Color_Black])&^fromBitboard | toBitboard
 
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						).withTakingPiece(pieceAt).withPawn(false))
-				}
-			}
+	var takenBitboard bitboard
+	if movement.isTakingPiece {
+		takenBitboard = bitboardOf(squareIndex(movement.takingPiece.Square))
+		occupied = occupied&^takenBitboard | toBitboard
+	}
+
+	kingIndex := squareIndex(movement.toSq)
+	if movement.movingPiece.Kind != Kind_King {
+		kings := p.pieceBitboards[color][Kind_King]
+		if kings == 0 {
+			*movements = append(*movements, movement)
+			return
 		}
+		kingIndex = kings.first()
+	}
 
+	if p.attackersOf(kingIndex, color.Opposite(), occupied)&^takenBitboard == 0 {
+		*movements = append(*movements, movement)
+	}
+}
+
+func (p Position) isInCheck() bool {
+	kings := p.pieceBitboards[p.playerToMove][Kind_King]
+	if kings == 0 {
+		return false
+	}
+
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	return p.attackersOf(kings.first(), p.playerToMove.Opposite(), occupied) != 0
+}
+
+// attackersOf returns the pieces of the passed color that attack the
+// square index, with the given occupancy.
+func (p *Position) attackersOf(index int, color Color, occupied bitboard) bitboard {
+	pieces := &p.pieceBitboards[color]
+	return (knightAttacks[index] & pieces[Kind_Knight]) |
+		(kingAttacks[index] & pieces[Kind_King]) |
+		(pawnAttacks[color.Opposite()][index] & pieces[Kind_Pawn]) |
+		(bishopAttacks(index, occupied) & (pieces[Kind_Bishop] | pieces[Kind_Queen])) |
+		(rookAttacks(index, occupied) & (pieces[Kind_Rook] | pieces[Kind_Queen]))
+}
+
+// setPiece places a piece at the square index, in both board and bitboards.
+//
+// Note: It assumes that the square is empty.
+func (p *Position) setPiece(index int, color Color, kind Kind) {
+	p.board[index/8][index%8].Color = color
+	p.board[index/8][index%8].Kind = kind
+	p.pieceBitboards[color][kind] |= bitboardOf(index)
+	p.colorBitboards[color] |= bitboardOf(index)
+	p.hash ^= pieceKey(index, color, kind)
+}
+
+// removePiece removes the piece at the square index, if any, from both
+// board and bitboards.
+func (p *Position) removePiece(index int) {
+	piece := p.board[index/8][index%8]
+	if piece.Kind == Kind_None {
 		return
 	}
+
+	p.pieceBitboards[piece.Color][piece.Kind] &^= bitboardOf(index)
+	p.colorBitboards[piece.Color] &^= bitboardOf(index)
+	p.hash ^= pieceKey(index, piece.Color, piece.Kind)
+	p.board[index/8][index%8].Color = Color_None
+	p.board[index/8][index%8].Kind = Kind_None
 }
 
-func (p Position) computeDirectionPseudoMovements(piece Piece, movements *[]Movement, attackMatrix *[8][8]bool, directions [4][2]int8) {
-	for _, dir := range directions {
-		i := int8(piece.Square.I) + dir[0]
-		j := int8(piece.Square.J) + dir[1]
+// undoRecord stores the state of a position that can not be recovered from
+// the movement itself, to undo the movement via unmakeMovement(). The captured
+// piece is already stored in the movement.
+type undoRecord struct {
+	castlingRights CastlingRights
+	enPassantSq    Square
+	hasEnPassant   bool
+	halfmoveClock  uint8
+	isChecked      bool
+	hash           uint64
+}
 
-		for i >= 0 && j >= 0 && i < 8 && j < 8 {
-			row := uint8(i)
-			col := uint8(j)
+// applyMovement returns a new position with the movement made. It assumes
+// that the movement is legal.
+//
+// Captures are only appended if trackCaptures is set.
+func (p *Position) applyMovement(movement Movement, trackCaptures bool) Position {
+	newPosition := p.clone()
+	newPosition.makeMovement(movement)
+
+	if trackCaptures && movement.isTakingPiece {
+		// Clip, so positions sharing the captures never overwrite each other's
+		newPosition.captures = append(slices.Clip(newPosition.captures), movement.takingPiece)
+	}
 
-			pieceAt := p.board[row][col]
+	return newPosition
+}
 
-			if pieceAt.Kind == Kind_None {
-				attackMatrix[row][col] = true
-				*movements = append(*movements,
-					*newMovement(piece,
-						piece.Square,
-						newSquare(row, col),
-					))
-			} else {
-				if pieceAt.Color != piece.Color {
-					attackMatrix[row][col] = true
-					*movements = append(*movements,
-						*newMovement(piece,
-							piece.Square,
-							newSquare(row, col),
-						).withTakingPiece(pieceAt))
-				}
+// makeMovement makes the movement in place, and returns the record needed to
+// undo it via unmakeMovement(). It assumes that the movement is legal.
+//
+// Note: Captures are not tracked, and isChecked is set to false.
+func (p *Position) makeMovement(movement Movement) undoRecord {
+	undo := undoRecord{
+		castlingRights: p.castlingRights,
+		enPassantSq:    p.enPassantSq,
+		hasEnPassant:   p.hasEnPassant,
+		halfmoveClock:  p.halfmoveClock,
+		isChecked:      p.isChecked,
+		hash:           p.hash,
+	}
 
-				break
+	color := movement.movingPiece.Color
+	fromIndex := squareIndex(movement.fromSq)
+	toIndex := squareIndex(movement.toSq)
+	castlingRow := castlingRows[color]
+
+	// Remove the keys of the current state, added back once updated
+	p.hash ^= p.stateKey()
+	p.hasEnPassant = false
+	p.isChecked = false
+
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		p.castlingRights.queenSide[color] = false
+		p.castlingRights.kingSide[color] = false
+
+		rookFromIndex, rookToIndex := castlingRookIndexes(movement)
+		p.removePiece(fromIndex)
+		p.removePiece(rookFromIndex)
+		p.setPiece(toIndex, color, Kind_King)
+		p.setPiece(rookToIndex, color, Kind_Rook)
+	} else {
+		if movement.movingPiece.Kind == Kind_Pawn {
+			if movement.isDoublePawnPush {
+				// Uint8 from that sum/rest, as it will never be negative in a starting double pawn
+				p.enPassantSq = newSquare(uint8(int8(movement.fromSq.I)+pawnMoveRowDirections[color]), movement.fromSq.J)
+				p.hasEnPassant = true
 			}
+		} else if movement.movingPiece.Kind == Kind_King {
+			p.castlingRights.queenSide[color] = false
+			p.castlingRights.kingSide[color] = false
+		} else if movement.movingPiece.Kind == Kind_Rook && movement.fromSq.I == castlingRow {
+			// Check if currently moving rook is from queen or king side
+			if movement.fromSq.J == p.castlingRights.queenSideRookFile[color] {
+				p.castlingRights.queenSide[color] = false
+			} else if movement.fromSq.J == p.castlingRights.kingSideRookFile[color] {
+				p.castlingRights.kingSide[color] = false
+			}
+		}
+
+		if movement.isTakingPiece {
+			p.removePiece(squareIndex(movement.takingPiece.Square))
 
-			i += dir[0]
-			j += dir[1]
+			if movement.takingPiece.Kind == Kind_Rook {
+				takenColor, takenSquare := movement.takingPiece.Color, movement.takingPiece.Square
+				if takenSquare.I == castlingRows[takenColor] {
+					if takenSquare.J == p.castlingRights.queenSideRookFile[takenColor] {
+						p.castlingRights.queenSide[takenColor] = false
+					} else if takenSquare.J == p.castlingRights.kingSideRookFile[takenColor] {
+						p.castlingRights.kingSide[takenColor] = false
+					}
+				}
+			}
 		}
+
+		p.removePiece(fromIndex)
+		if movement.pawnPromotionTo == Kind_None {
+			p.setPiece(toIndex, color, movement.movingPiece.Kind)
+		} else {
+			// Promote the piece
+			p.setPiece(toIndex, color, movement.pawnPromotionTo)
+		}
+	}
+
+	// Handle halfmove clock
+	if movement.movingPiece.Kind == Kind_Pawn || movement.isTakingPiece {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
 	}
 
-	return
+	// Handle fullmove counter
+	if color == Color_White {
+		p.playerToMove = Color_Black
+	} else if color == Color_Black {
+		p.playerToMove = Color_White
+		p.fullmoveCounter++
+	}
+
+	p.hash ^= p.stateKey()
+
+	return undo
 }
 
-// TODO: Save king's positions (in Position{}, or get them at computePseudoMovements() to reuse the loop, if possible)
-func (p Position) checkForCheck(allyColor Color, opponentAttackMatrix *[8][8]bool) bool {
-	for i := uint8(0); i < 8; i++ {
-		for j := uint8(0); j < 8; j++ {
-			if p.board[i][j].Kind == Kind_King && p.board[i][j].Color == allyColor {
-				return opponentAttackMatrix[i][j]
-			}
+// unmakeMovement undoes the movement made via makeMovement(), restoring the
+// state stored in the passed undo record.
+func (p *Position) unmakeMovement(movement Movement, undo undoRecord) {
+	color := movement.movingPiece.Color
+	fromIndex := squareIndex(movement.fromSq)
+	toIndex := squareIndex(movement.toSq)
+
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		rookFromIndex, rookToIndex := castlingRookIndexes(movement)
+		p.removePiece(toIndex)
+		p.removePiece(rookToIndex)
+		p.setPiece(fromIndex, color, Kind_King)
+		p.setPiece(rookFromIndex, color, Kind_Rook)
+	} else {
+		p.removePiece(toIndex)
+		p.setPiece(fromIndex, color, movement.movingPiece.Kind)
+
+		if movement.isTakingPiece {
+			p.setPiece(squareIndex(movement.takingPiece.Square), movement.takingPiece.Color, movement.takingPiece.Kind)
 		}
 	}
 
-	// Won't get here, unless there is no King piece ??
-	return false
+	if color == Color_Black {
+		p.fullmoveCounter--
+	}
+	p.playerToMove = color
+
+	p.castlingRights = undo.castlingRights
+	p.enPassantSq = undo.enPassantSq
+	p.hasEnPassant = undo.hasEnPassant
+	p.halfmoveClock = undo.halfmoveClock
+	p.isChecked = undo.isChecked
+	p.hash = undo.hash
+}
+
+// castlingRookIndexes returns the square indexes where the rook of the
+// castling movement starts and ends.
+func castlingRookIndexes(movement Movement) (int, int) {
+	castlingRow := int(castlingRows[movement.movingPiece.Color])
+	if movement.isQueenSideCastling {
+		return squareIndex(movement.castlingRookSq), castlingRow*8 + 3
+	}
+	return squareIndex(movement.castlingRookSq), castlingRow*8 + 5
 }
@@ -0,0 +1,64 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestPositionLegalMovementsAndApply(t *testing.T) {
+	game, _ := NewGame("")
+	position := game.CurrentPosition()
+
+	if len(position.LegalMovements()) != 20 {
+		t.Fatalf("Expected 20 legal movements, got %d", len(position.LegalMovements()))
+	}
+
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		movement, err := ParseSAN(position, san)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %s", san, err)
+		}
+		position = position.Apply(movement)
+	}
+
+	if !position.IsCheckmate() || position.IsStalemate() || !position.IsChecked() {
+		t.Errorf("Expected %q to be checkmate", position.Fen())
+	}
+	if game.CurrentFen() != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Errorf("Expected the game to remain unchanged, got %q", game.CurrentFen())
+	}
+
+	stalemate, _ := NewGame("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	if !stalemate.CurrentPosition().IsStalemate() || stalemate.CurrentPosition().IsCheckmate() {
+		t.Errorf("Expected %q to be stalemate", stalemate.CurrentFen())
+	}
+}
+
+func TestMakeUnmakeMovement(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	}
+
+	for _, fen := range fens {
+		game, _ := NewGame(fen)
+		position := game.CurrentPosition()
+
+		// Every movement must restore the exact same position
+		for _, movement := range position.LegalMovements() {
+			undo := position.makeMovement(movement)
+			position.unmakeMovement(movement, undo)
+
+			if position.Fen() != fen || position.pieceBitboards != game.currentPosition.pieceBitboards {
+				t.Fatalf("Expected %s to restore %q, got %q", movement.Algebraic(), fen, position.Fen())
+			}
+		}
+
+		buffers := newPerftBuffers(3)
+		allocations := testing.AllocsPerRun(1, func() {
+			position.perft(3, buffers)
+		})
+		if allocations != 0 {
+			t.Errorf("Expected perft of %q to make no allocations, got %f", fen, allocations)
+		}
+	}
+}
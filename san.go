@@ -0,0 +1,294 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MovementSAN returns the Standard Algebraic Notation of the passed movement,
+// played in the game's current position.
+//
+// If the movement is not legal in the current position, it will return an
+// empty string and the error.
+//
+// Examples outputs:
+//
+//	"Nf3"
+//	"exd6"
+//	"O-O-O"
+//	"e8=Q+"
+//	"Qxf7#"
+func (g *Game) MovementSAN(movement Movement) (string, error) {
+	for _, legalMovement := range g.computedLegalMovements {
+		if legalMovement.Algebraic() == movement.Algebraic() {
+			return g.movementSAN(legalMovement), nil
+		}
+	}
+
+	return "", errors.New("That movement is not allowed or is invalid.")
+}
+
+// LegalMovementsSAN returns a slice of legal movements of the
+// current position's turn, in Standard Algebraic Notation strings.
+//
+// If no movements are legal, it will return an empty list.
+//
+// Example:
+//
+//	LegalMovementsSAN() // returns ["d3", "Nf3", "O-O", ...]
+//	LegalMovementsSAN() // returns []
+func (g *Game) LegalMovementsSAN() []string {
+	movementList := make([]string, len(g.computedLegalMovements))
+	for i, legalMovement := range g.computedLegalMovements {
+		movementList[i] = g.movementSAN(legalMovement)
+	}
+	return movementList
+}
+
+// MovementHistorySAN returns a slice of the Movements made in the game, in
+// Standard Algebraic Notation, beginning with the first move and ending with
+// the most recent one.
+func (g *Game) MovementHistorySAN() []string {
+	startingPosition, _ := g.PositionAtIndex(0)
+	replay := newGameFromPosition(startingPosition)
+
+	movementList := make([]string, len(g.movementHistory))
+	for i, movement := range g.movementHistory {
+		movementList[i] = replay.movementSAN(movement)
+		replay.forceMovement(movement, true)
+	}
+	return movementList
+}
+
+// movementSAN assumes that the movement is legal in the current position.
+func (g *Game) movementSAN(movement Movement) string {
+	var sb strings.Builder
+
+	if movement.isKingSideCastling {
+		sb.WriteString("O-O")
+	} else if movement.isQueenSideCastling {
+		sb.WriteString("O-O-O")
+	} else if movement.movingPiece.Kind == Kind_Pawn {
+		if movement.isTakingPiece {
+			sb.WriteRune(rune(movement.fromSq.J) + 'a')
+			sb.WriteRune('x')
+		}
+
+		sb.WriteString(movement.toSq.Algebraic())
+
+		if movement.pawnPromotionTo != Kind_None {
+			sb.WriteRune('=')
+			sb.WriteRune(unicode.ToUpper(movement.pawnPromotionTo.Rune()))
+		}
+	} else {
+		sb.WriteRune(unicode.ToUpper(movement.movingPiece.Kind.Rune()))
+		sb.WriteString(g.sanDisambiguation(movement))
+
+		if movement.isTakingPiece {
+			sb.WriteRune('x')
+		}
+
+		sb.WriteString(movement.toSq.Algebraic())
+	}
+
+	isCheck, isCheckmate := g.movementGivesCheck(movement)
+	if isCheckmate {
+		sb.WriteRune('#')
+	} else if isCheck {
+		sb.WriteRune('+')
+	}
+
+	return sb.String()
+}
+
+// sanDisambiguation returns the origin file, rank or square needed to tell
+// the movement apart from other legal movements of the same piece kind
+// to the same square. If none is needed, it will return an empty string.
+func (g *Game) sanDisambiguation(movement Movement) string {
+	isAmbiguous, sameFile, sameRank := false, false, false
+
+	for _, legalMovement := range g.computedLegalMovements {
+		if legalMovement.movingPiece.Kind != movement.movingPiece.Kind ||
+			!legalMovement.toSq.IsEqualTo(movement.toSq) ||
+			legalMovement.fromSq.IsEqualTo(movement.fromSq) {
+			continue
+		}
+
+		isAmbiguous = true
+		if legalMovement.fromSq.J == movement.fromSq.J {
+			sameFile = true
+		}
+		if legalMovement.fromSq.I == movement.fromSq.I {
+			sameRank = true
+		}
+	}
+
+	if !isAmbiguous {
+		return ""
+	} else if !sameFile {
+		return string(rune(movement.fromSq.J) + 'a')
+	} else if !sameRank {
+		return string('8' - rune(movement.fromSq.I))
+	}
+
+	return movement.fromSq.Algebraic()
+}
+
+// movementGivesCheck reports whether the movement leaves the opponent
+// under check, and whether it also leaves it without legal movements.
+func (g *Game) movementGivesCheck(movement Movement) (bool, bool) {
+	legalMovements := g.computedLegalMovements
+
+	undo := g.simulateMovement(movement)
+	g.computeLegalMovements()
+	isCheck := g.currentPosition.isChecked
+	isCheckmate := isCheck && len(g.computedLegalMovements) == 0
+	g.undoSimulatedMovement(movement, undo)
+
+	g.computedLegalMovements = legalMovements
+
+	return isCheck, isCheckmate
+}
+
+// ParseSAN returns the legal Movement of the passed position that matches the
+// given Standard Algebraic Notation string.
+//
+// Castling can be written both as "O-O" and "0-0", and trailing check, checkmate
+// and annotation symbols ("+", "#", "!", "?") are ignored.
+//
+// If the movement is invalid, illegal or ambiguous, it will return an empty
+// Movement and the error.
+//
+// Examples:
+//
+//	ParseSAN(position, "Nf3")   // returns Movement{...}, nil
+//	ParseSAN(position, "exd6")  // returns Movement{...}, nil
+//	ParseSAN(position, "Nbd7")  // returns Movement{}, error (if no knight in b-file)
+//	ParseSAN(position, "Nd7")   // returns Movement{}, error (if two knights can go to d7)
+func ParseSAN(position Position, san string) (Movement, error) {
+	game := newGameFromPosition(position)
+	return game.parseSAN(san)
+}
+
+// MakeMovementSAN tries to make the given movement in Standard
+// Algebraic Notation.
+//
+// If the movement is invalid, illegal or ambiguous, it will return an error.
+func (g *Game) MakeMovementSAN(san string) error {
+	movement, err := g.parseSAN(san)
+	if err != nil {
+		return err
+	}
+
+	return g.MakeMovement(movement)
+}
+
+func (g *Game) parseSAN(san string) (Movement, error) {
+	text := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "e.p."), " ")
+
+	if text == "" {
+		return Movement{}, errors.New("The provided SAN movement is empty.")
+	}
+
+	switch text {
+	case "O-O", "0-0":
+		return g.findCastlingMovement(san, false)
+	case "O-O-O", "0-0-0":
+		return g.findCastlingMovement(san, true)
+	}
+
+	kind := Kind_Pawn
+	if strings.ContainsRune("KQRBN", rune(text[0])) {
+		kind = KindFromRune(rune(text[0]))
+		text = text[1:]
+	}
+
+	promotionKind := Kind_None
+	if kind == Kind_Pawn && len(text) > 0 {
+		if last := rune(text[len(text)-1]); strings.ContainsRune("QRBNqrbn", last) {
+			promotionKind = KindFromRune(last)
+			text = strings.TrimSuffix(text[:len(text)-1], "=")
+		}
+	}
+
+	if len(text) < 2 {
+		return Movement{}, fmt.Errorf("The SAN movement \"%s\" does not have a valid destination square.", san)
+	}
+
+	toSq, err := NewSquareFromAlgebraic(text[len(text)-2:])
+	if err != nil {
+		return Movement{}, fmt.Errorf("The SAN movement \"%s\" does not have a valid destination square.", san)
+	}
+	text = text[:len(text)-2]
+
+	isCapture := strings.HasSuffix(text, "x") || strings.HasSuffix(text, ":")
+	if isCapture {
+		text = text[:len(text)-1]
+	}
+
+	// Remaining text is the (optional) origin disambiguation
+	fromFile, fromRank := -1, -1
+	for _, r := range text {
+		if r >= 'a' && r <= 'h' && fromFile == -1 {
+			fromFile = int(r - 'a')
+		} else if r >= '1' && r <= '8' && fromRank == -1 {
+			fromRank = int('8' - r)
+		} else {
+			return Movement{}, fmt.Errorf("The SAN movement \"%s\" has an invalid origin disambiguation.", san)
+		}
+	}
+
+	if kind == Kind_Pawn && isCapture && fromFile == -1 {
+		return Movement{}, fmt.Errorf("The SAN movement \"%s\" is a pawn capture without origin file.", san)
+	}
+
+	var candidates []Movement
+	for _, legalMovement := range g.computedLegalMovements {
+		if legalMovement.isKingSideCastling || legalMovement.isQueenSideCastling {
+			continue
+		}
+		if legalMovement.movingPiece.Kind != kind || !legalMovement.toSq.IsEqualTo(toSq) {
+			continue
+		}
+		if fromFile != -1 && int(legalMovement.fromSq.J) != fromFile {
+			continue
+		}
+		if fromRank != -1 && int(legalMovement.fromSq.I) != fromRank {
+			continue
+		}
+		if isCapture && !legalMovement.isTakingPiece {
+			continue
+		}
+
+		if legalMovement.pawnPromotionTo == Kind_None {
+			if promotionKind != Kind_None {
+				continue
+			}
+		} else if legalMovement.pawnPromotionTo != promotionKind {
+			continue
+		}
+
+		candidates = append(candidates, legalMovement)
+	}
+
+	if len(candidates) == 0 {
+		return Movement{}, fmt.Errorf("The SAN movement \"%s\" is not allowed or is invalid.", san)
+	} else if len(candidates) > 1 {
+		return Movement{}, fmt.Errorf("The SAN movement \"%s\" is ambiguous.", san)
+	}
+
+	return candidates[0], nil
+}
+
+func (g *Game) findCastlingMovement(san string, isQueenSide bool) (Movement, error) {
+	for _, legalMovement := range g.computedLegalMovements {
+		if (isQueenSide && legalMovement.isQueenSideCastling) || (!isQueenSide && legalMovement.isKingSideCastling) {
+			return legalMovement, nil
+		}
+	}
+
+	return Movement{}, fmt.Errorf("The SAN movement \"%s\" is not allowed or is invalid.", san)
+}
@@ -0,0 +1,86 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestMovementSAN(t *testing.T) {
+	tests := []struct {
+		fen       string
+		algebraic string
+		san       string
+	}{
+		{"", "g1f3", "Nf3"},
+		{"", "e2e4", "e4"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/3K3R b kq - 0 1", "e8c8", "O-O-O+"},
+		{"3k4/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e8=Q+"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 2 3", "f3f7", "Qxf7#"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "Rad1"},
+		{"4k3/8/8/R7/8/8/8/R3K3 w - - 0 1", "a1a3", "R1a3"},
+		{"4k3/8/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "a1b2", "Qa1b2"},
+	}
+
+	for _, test := range tests {
+		game, err := NewGame(test.fen)
+		if err != nil {
+			t.Fatalf("Invalid test FEN %q: %s", test.fen, err)
+		}
+
+		var movement Movement
+		found := false
+		for _, legalMovement := range game.LegalMovements() {
+			if legalMovement.Algebraic() == test.algebraic {
+				movement, found = legalMovement, true
+			}
+		}
+		if !found {
+			t.Fatalf("Invalid test movement %s in %q", test.algebraic, test.fen)
+		}
+
+		san, err := game.MovementSAN(movement)
+		if err != nil || san != test.san {
+			t.Errorf("SAN of %s in %q: expected %q, got %q (%v)", test.algebraic, test.fen, test.san, san, err)
+		}
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		fen       string
+		san       string
+		algebraic string // Empty if an error is expected
+	}{
+		{"", "Nf3", "g1f3"},
+		{"", "e4!?", "e2e4"},
+		{"", "Ne2", ""},
+		{"", "Qd4", ""},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", "e8c8"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", "e5f6"},
+		{"1r1k4/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=N+", "a7b8n"},
+		{"1r1k4/P7/8/8/8/8/8/4K3 w - - 0 1", "a8Q", "a7a8q"},
+		{"1r1k4/P7/8/8/8/8/8/4K3 w - - 0 1", "a8", ""},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rd1", ""},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "Rad1", "a1d1"},
+		{"4k3/8/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "Qa1b2", "a1b2"},
+		{"4k3/8/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "Q1b2", ""},
+	}
+
+	for _, test := range tests {
+		game, err := NewGame(test.fen)
+		if err != nil {
+			t.Fatalf("Invalid test FEN %q: %s", test.fen, err)
+		}
+
+		movement, err := ParseSAN(game.CurrentPosition(), test.san)
+		if test.algebraic == "" {
+			if err == nil {
+				t.Errorf("Parsing %q in %q: expected an error, got %s", test.san, test.fen, movement.Algebraic())
+			}
+		} else if err != nil || movement.Algebraic() != test.algebraic {
+			t.Errorf("Parsing %q in %q: expected %s, got %s (%v)", test.san, test.fen, test.algebraic, movement.Algebraic(), err)
+		}
+	}
+}
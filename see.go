@@ -0,0 +1,232 @@
+package chess
+
+// Value of the king in static exchange evaluations, higher than any amount
+// of material, as it can't be captured.
+const seeKingValue = 20000
+
+func seeValue(kind Kind) int {
+	if kind == Kind_King {
+		return seeKingValue
+	}
+	return materialValues[kind].mg
+}
+
+// SEE (Static Exchange Evaluation) returns the material won or lost by the
+// player to move, in centipawns, after the movement and the best sequence
+// of captures and recaptures on its target square, including the attacks
+// of sliding pieces behind other attackers (x-rays).
+//
+// Each player may stop capturing whenever continuing loses material. Pins
+// and checks are not considered. Non capturing movements return 0, unless
+// the moved piece can be taken, or they are promotions.
+//
+// Examples:
+//
+//	"4k3/8/3p4/4p3/8/8/8/4KR2 w" // f1f5 is not a capture, SEE returns 0
+//	"4k3/8/3p4/4p3/8/8/8/4R1K1 w" // e1e5 returns -400 (wins a pawn, loses a rook)
+//	"4k3/8/8/4p3/8/8/4R3/4R1K1 w" // e2e5 returns 100
+func (p Position) SEE(movement Movement) int {
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		return 0
+	}
+
+	var gain [32]int
+
+	from, to := squareIndex(movement.fromSq), squareIndex(movement.toSq)
+	occupied, attackers := p.seeAttackers(movement)
+
+	gain[0] = p.seeCapturedValue(movement)
+	attackerKind := movement.movingPiece.Kind
+	if movement.pawnPromotionTo != Kind_None {
+		attackerKind = movement.pawnPromotionTo
+	}
+
+	fromBitboard := bitboardOf(from)
+	color := movement.movingPiece.Color
+
+	depth := 0
+	for depth < len(gain)-1 {
+		// Speculative gain of the next capture, if the square is defended
+		depth++
+		gain[depth] = seeValue(attackerKind) - gain[depth-1]
+
+		occupied &^= fromBitboard
+		attackers |= p.xrayAttacker(to, fromBitboard.first(), occupied)
+		attackers &= occupied
+
+		color = color.Opposite()
+		fromBitboard, attackerKind = p.leastValuableAttacker(attackers, color)
+		if fromBitboard == 0 {
+			break
+		}
+
+		// The king can't capture on a defended square
+		if attackerKind == Kind_King && attackers&p.colorBitboards[color.Opposite()] != 0 {
+			break
+		}
+	}
+
+	for depth--; depth > 0; depth-- {
+		gain[depth-1] = -max(-gain[depth-1], gain[depth])
+	}
+
+	return gain[0]
+}
+
+// SEEGreaterOrEqual reports whether the static exchange evaluation of the
+// movement is greater than or equal to the threshold. It's faster than
+// comparing SEE's result, as it stops as soon as the result is known.
+//
+// Examples:
+//
+//	"4k3/8/3p4/4p3/8/8/8/4R1K1 w" // e1e5 with threshold 0 returns false
+//	"4k3/8/3p4/4p3/8/8/8/4R1K1 w" // e1e5 with threshold -400 returns true
+func (p Position) SEEGreaterOrEqual(movement Movement, threshold int) bool {
+	if movement.isQueenSideCastling || movement.isKingSideCastling {
+		return threshold <= 0
+	}
+
+	from, to := squareIndex(movement.fromSq), squareIndex(movement.toSq)
+	occupied, attackers := p.seeAttackers(movement)
+
+	attackerKind := movement.movingPiece.Kind
+	if movement.pawnPromotionTo != Kind_None {
+		attackerKind = movement.pawnPromotionTo
+	}
+
+	// Balance after the movement, if it's not recaptured
+	swap := p.seeCapturedValue(movement) - threshold
+	if swap < 0 {
+		return false
+	}
+
+	// Balance after the moved piece is recaptured
+	swap = seeValue(attackerKind) - swap
+	if swap <= 0 {
+		return true
+	}
+
+	fromBitboard := bitboardOf(from)
+	color := movement.movingPiece.Color
+	isGreaterOrEqual := true
+
+	for {
+		occupied &^= fromBitboard
+		attackers |= p.xrayAttacker(to, fromBitboard.first(), occupied)
+		attackers &= occupied
+
+		color = color.Opposite()
+		fromBitboard, attackerKind = p.leastValuableAttacker(attackers, color)
+		if fromBitboard == 0 {
+			break
+		}
+
+		// Each capture flips the result, until the capturing player can
+		// stop with a favorable balance
+		isGreaterOrEqual = !isGreaterOrEqual
+
+		// The king can only capture if the square is no longer defended
+		if attackerKind == Kind_King {
+			if attackers&p.colorBitboards[color.Opposite()] != 0 {
+				isGreaterOrEqual = !isGreaterOrEqual
+			}
+			break
+		}
+
+		swap = seeValue(attackerKind) - swap
+		if isGreaterOrEqual && swap < 1 || !isGreaterOrEqual && swap < 0 {
+			break
+		}
+	}
+
+	return isGreaterOrEqual
+}
+
+// seeCapturedValue returns the value of the piece taken by the movement,
+// plus the value gained by its pawn promotion, if any.
+func (p *Position) seeCapturedValue(movement Movement) int {
+	value := 0
+	if movement.isTakingPiece {
+		value = seeValue(movement.takingPiece.Kind)
+	}
+	if movement.pawnPromotionTo != Kind_None {
+		value += seeValue(movement.pawnPromotionTo) - seeValue(Kind_Pawn)
+	}
+	return value
+}
+
+// seeAttackers returns the occupancy of the board after the moved piece
+// leaves its square (and the pawn taken en passant, if any), and the pieces
+// of both colors that attack the movement's target square with it.
+func (p *Position) seeAttackers(movement Movement) (bitboard, bitboard) {
+	to := squareIndex(movement.toSq)
+
+	occupied := p.colorBitboards[Color_White] | p.colorBitboards[Color_Black]
+	if movement.isTakingPiece && squareIndex(movement.takingPiece.Square) != to {
+		occupied &^= bitboardOf(squareIndex(movement.takingPiece.Square))
+	}
+
+	attackers := p.attackersOf(to, Color_White, occupied) | p.attackersOf(to, Color_Black, occupied)
+	return occupied, attackers & occupied
+}
+
+// leastValuableAttacker returns the least valuable of the attackers of the
+// passed color, and its kind. If there are none, it returns an empty set.
+func (p *Position) leastValuableAttacker(attackers bitboard, color Color) (bitboard, Kind) {
+	for _, kind := range [KIND_AMOUNT]Kind{Kind_Pawn, Kind_Knight, Kind_Bishop, Kind_Rook, Kind_Queen, Kind_King} {
+		if pieces := attackers & p.pieceBitboards[color][kind]; pieces != 0 {
+			return bitboardOf(pieces.first()), kind
+		}
+	}
+	return 0, Kind_None
+}
+
+// xrayAttacker returns the sliding piece, of any color, found behind the
+// square index "from" when looking from the target square index, if it
+// attacks in that direction. It returns an empty set otherwise.
+func (p *Position) xrayAttacker(target, from int, occupied bitboard) bitboard {
+	rowDelta, columnDelta := from/8-target/8, from%8-target%8
+	if rowDelta != 0 && columnDelta != 0 && abs(rowDelta) != abs(columnDelta) {
+		return 0 // Not aligned, as a knight
+	}
+
+	direction := [2]int8{int8(sign(rowDelta)), int8(sign(columnDelta))}
+
+	sliders := p.pieceBitboards[Color_White][Kind_Queen] | p.pieceBitboards[Color_Black][Kind_Queen]
+	for _, bishopDirection := range bishopDirections {
+		if bishopDirection == direction {
+			sliders |= p.pieceBitboards[Color_White][Kind_Bishop] | p.pieceBitboards[Color_Black][Kind_Bishop]
+		}
+	}
+	for _, rookDirection := range rookDirections {
+		if rookDirection == direction {
+			sliders |= p.pieceBitboards[Color_White][Kind_Rook] | p.pieceBitboards[Color_Black][Kind_Rook]
+		}
+	}
+
+	i, j := from/8+int(direction[0]), from%8+int(direction[1])
+	for ; i >= 0 && i < 8 && j >= 0 && j < 8; i, j = i+int(direction[0]), j+int(direction[1]) {
+		if index := i*8 + j; occupied.has(index) {
+			return bitboardOf(index) & sliders
+		}
+	}
+
+	return 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		fen  string
+		san  string
+		want int
+	}{
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "Rxe5", 100},
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "Nxe5", -220},
+		{"4k3/8/3p4/4p3/8/8/8/4R1K1 w - - 0 1", "Rxe5", -400},
+		{"4k3/8/3p4/4p3/8/8/8/4KR2 w - - 0 1", "Rf5", 0},
+		{"4k3/8/3p4/8/8/8/8/4KR2 w - - 0 1", "Rf6", 0},
+		{"4k3/8/4p3/8/8/8/8/4KR2 w - - 0 1", "Rf5", -500},
+
+		// X-rays: the second rook recaptures after the first one is taken
+		{"4k3/4r3/8/4p3/8/8/4R3/4R1K1 w - - 0 1", "Rxe5", 100},
+		{"4k3/4r3/8/4p3/8/8/4R3/6K1 w - - 0 1", "Rxe5", -400},
+		{"4k3/8/8/4p3/8/2B5/1Q6/6K1 w - - 0 1", "Bxe5", 100},
+
+		// The king can't capture on a defended square
+		{"8/8/8/3k4/4p3/8/8/4R1K1 w - - 0 1", "Rxe4", -400},
+		{"8/8/8/3k4/4p3/8/8/1B2R1K1 w - - 0 1", "Rxe4", 100},
+		{"8/8/2n5/4k3/3p4/8/3R4/3R2K1 w - - 0 1", "Rxd4", -400},
+
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", 100},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b8=Q", 800},
+		{"r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b8=Q+", -100},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", 0},
+	}
+
+	for _, test := range tests {
+		game, err := NewGame(test.fen)
+		if err != nil {
+			t.Fatalf("Unexpected error creating game %q: %s", test.fen, err)
+		}
+		position := game.CurrentPosition()
+
+		movement, err := ParseSAN(position, test.san)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s in %q: %s", test.san, test.fen, err)
+			continue
+		}
+
+		if got := position.SEE(movement); got != test.want {
+			t.Errorf("Expected SEE of %s in %q to be %d, got %d", test.san, test.fen, test.want, got)
+		}
+		if !position.SEEGreaterOrEqual(movement, test.want) || position.SEEGreaterOrEqual(movement, test.want+1) {
+			t.Errorf("Expected SEEGreaterOrEqual of %s in %q to be true only up to %d", test.san, test.fen, test.want)
+		}
+	}
+}
+
+func TestSEEGreaterOrEqualMatchesSEE(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1",
+	}
+
+	for _, fen := range fens {
+		game, _ := NewGame(fen)
+		position := game.CurrentPosition()
+
+		for _, movement := range position.LegalMovements() {
+			see := position.SEE(movement)
+			for threshold := -1000; threshold <= 1000; threshold += 10 {
+				if got := position.SEEGreaterOrEqual(movement, threshold); got != (see >= threshold) {
+					t.Errorf("Expected SEEGreaterOrEqual of %s in %q with threshold %d to be %t (SEE %d)", movement.Algebraic(), fen, threshold, see >= threshold, see)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,445 @@
+// Package uci implements the Universal Chess Interface (UCI) protocol: a
+// client, to drive external chess engines from a chess.Game, and a server,
+// to use a search engine written in Go from any UCI GUI.
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// Engine represents a connection to a UCI chess engine.
+//
+// Note: An Engine is not safe for concurrent use, except for Stop(), which
+// can be called while Go() is running.
+type Engine struct {
+	w       io.Writer
+	writeMu sync.Mutex
+
+	lines   chan string
+	readErr error // Set before lines is closed
+
+	cmd    *exec.Cmd
+	closer io.Closer
+
+	// Name and Author are the engine's identification, set by UCI().
+	Name   string
+	Author string
+
+	// Options are the engine's options, keyed by name, set by UCI().
+	Options map[string]Option
+
+	// StopTimeout is how long Go() waits for the best movement, once its
+	// context is done and "stop" is sent. Defaults to DefaultStopTimeout.
+	StopTimeout time.Duration
+}
+
+// DefaultStopTimeout is the default StopTimeout of new engines.
+const DefaultStopTimeout = 5 * time.Second
+
+// Option represents an option supported by the engine.
+type Option struct {
+	Name    string
+	Type    string // "check", "spin", "combo", "button" or "string"
+	Default string
+	Min     int      // Only for "spin" options
+	Max     int      // Only for "spin" options
+	Vars    []string // Only for "combo" options
+}
+
+// New creates and returns a new Engine that communicates through the passed
+// io.ReadWriter, such as a network connection or a fake engine in tests.
+//
+// If the io.ReadWriter is also an io.Closer, it will be closed by Close().
+func New(rw io.ReadWriter) *Engine {
+	e := &Engine{
+		w:       rw,
+		lines:   make(chan string, 64),
+		Options: make(map[string]Option),
+
+		StopTimeout: DefaultStopTimeout,
+	}
+
+	if closer, ok := rw.(io.Closer); ok {
+		e.closer = closer
+	}
+
+	go e.readLines(rw)
+
+	return e
+}
+
+// Start spawns the engine binary at the passed path with the given
+// arguments, and returns an Engine that communicates with it.
+//
+// Note: The UCI handshake is not done. Use UCI() after starting the engine.
+func Start(path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("The engine could not be started: %w", err)
+	}
+
+	e := New(struct {
+		io.Reader
+		io.Writer
+	}{stdout, stdin})
+	e.cmd = cmd
+	e.closer = stdin
+
+	return e, nil
+}
+
+// Close sends the "quit" command to the engine, and releases its resources.
+// If the engine was spawned via Start(), it waits for the process to exit.
+func (e *Engine) Close() error {
+	quitErr := e.send("quit")
+
+	if e.closer != nil {
+		e.closer.Close()
+	}
+
+	if e.cmd != nil {
+		if err := e.cmd.Wait(); err != nil {
+			return err
+		}
+	}
+
+	return quitErr
+}
+
+// UCI sends the "uci" command, and reads the engine's identification and
+// options until "uciok" is received.
+func (e *Engine) UCI(ctx context.Context) error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+
+	return e.readUntil(ctx, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return false
+		}
+
+		switch fields[0] {
+		case "id":
+			if len(fields) >= 3 && fields[1] == "name" {
+				e.Name = strings.Join(fields[2:], " ")
+			} else if len(fields) >= 3 && fields[1] == "author" {
+				e.Author = strings.Join(fields[2:], " ")
+			}
+		case "option":
+			if option, ok := parseOption(fields[1:]); ok {
+				e.Options[option.Name] = option
+			}
+		case "uciok":
+			return true
+		}
+
+		return false
+	})
+}
+
+// IsReady sends the "isready" command, and waits until the engine
+// answers with "readyok".
+func (e *Engine) IsReady(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+
+	return e.readUntil(ctx, func(line string) bool {
+		return strings.TrimSpace(line) == "readyok"
+	})
+}
+
+// SetOption sets the value of an engine's option. For "button" options,
+// the value is ignored.
+//
+// Examples:
+//
+//	SetOption("Hash", "128")
+//	SetOption("UCI_Chess960", "true")
+func (e *Engine) SetOption(name, value string) error {
+	if option, ok := e.Options[name]; (ok && option.Type == "button") || value == "" {
+		return e.send("setoption name " + name)
+	}
+	return e.send("setoption name " + name + " value " + value)
+}
+
+// NewGame sends the "ucinewgame" command, to tell the engine that the next
+// position is from a different game.
+func (e *Engine) NewGame() error {
+	return e.send("ucinewgame")
+}
+
+// Position sends the game's starting position and movements made to the engine.
+//
+// Note: For Chess960 games, the "UCI_Chess960" option must be set.
+func (e *Engine) Position(game *chess.Game) error {
+	var sb strings.Builder
+
+	sb.WriteString("position ")
+	if startingFen := game.StartingFen(); startingFen == "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		sb.WriteString("startpos")
+	} else {
+		sb.WriteString("fen " + startingFen)
+	}
+
+	if movements := game.MovementHistory(); len(movements) > 0 {
+		sb.WriteString(" moves")
+		for _, movement := range movements {
+			sb.WriteString(" " + movement.Algebraic())
+		}
+	}
+
+	return e.send(sb.String())
+}
+
+// GoParams represents the search parameters of the "go" command. Zero
+// values are not sent.
+type GoParams struct {
+	Depth     int
+	Nodes     uint64
+	Mate      int
+	MoveTime  time.Duration
+	WTime     time.Duration
+	BTime     time.Duration
+	WInc      time.Duration
+	BInc      time.Duration
+	MovesToGo int
+	Infinite  bool
+	Ponder    bool
+
+	// SearchMoves restricts the search to the movements, in Pure algebraic notation.
+	SearchMoves []string
+}
+
+func (p GoParams) String() string {
+	var sb strings.Builder
+	sb.WriteString("go")
+
+	if len(p.SearchMoves) > 0 {
+		sb.WriteString(" searchmoves " + strings.Join(p.SearchMoves, " "))
+	}
+	if p.Ponder {
+		sb.WriteString(" ponder")
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{{"wtime", p.WTime}, {"btime", p.BTime}, {"winc", p.WInc}, {"binc", p.BInc}, {"movetime", p.MoveTime}}
+	for _, duration := range durations {
+		if duration.value > 0 {
+			sb.WriteString(" " + duration.name + " " + strconv.FormatInt(duration.value.Milliseconds(), 10))
+		}
+	}
+
+	integers := []struct {
+		name  string
+		value uint64
+	}{{"movestogo", uint64(p.MovesToGo)}, {"depth", uint64(p.Depth)}, {"nodes", p.Nodes}, {"mate", uint64(p.Mate)}}
+	for _, integer := range integers {
+		if integer.value > 0 {
+			sb.WriteString(" " + integer.name + " " + strconv.FormatUint(integer.value, 10))
+		}
+	}
+
+	if p.Infinite {
+		sb.WriteString(" infinite")
+	}
+
+	return sb.String()
+}
+
+// SearchResult represents the result of a search.
+type SearchResult struct {
+	BestMove string // In Pure algebraic notation, or "(none)" if there are no legal movements
+	Ponder   string // Empty if the engine did not send a movement to ponder
+
+	// Infos contains the last info with a principal variation of each
+	// MultiPV line, with the best line first.
+	Infos []Info
+}
+
+// Go starts a search with the passed parameters, calling onInfo (if not nil)
+// for each "info" line received, and returns once the engine sends its best
+// movement. Malformed "info" lines are skipped.
+//
+// If the context is done before the search ends, "stop" is sent to the
+// engine, and its best movement is still returned. If the engine doesn't
+// send it within StopTimeout, the context's error is returned instead.
+//
+// Note: If Go() returns the context's error, the engine didn't answer, so
+// it should be closed, as a late best movement would be read by the next
+// command.
+func (e *Engine) Go(ctx context.Context, params GoParams, onInfo func(Info)) (SearchResult, error) {
+	if err := e.send(params.String()); err != nil {
+		return SearchResult{}, err
+	}
+
+	var result SearchResult
+	var parseErr error
+
+	// Stop the search once the context is done, but keep reading until the
+	// best movement, or until the engine takes too long to send it
+	readCtx, cancelRead := context.WithCancel(context.Background())
+	defer cancelRead()
+	stopWatching := context.AfterFunc(ctx, func() {
+		e.Stop()
+		time.AfterFunc(e.StopTimeout, cancelRead)
+	})
+	defer stopWatching()
+
+	err := e.readUntil(readCtx, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return false
+		}
+
+		switch fields[0] {
+		case "info":
+			info, err := ParseInfo(line)
+			if err != nil {
+				return false
+			}
+
+			if len(info.PV) > 0 {
+				result.addInfo(info)
+			}
+			if onInfo != nil {
+				onInfo(info)
+			}
+		case "bestmove":
+			if len(fields) < 2 {
+				parseErr = errors.New("The engine sent a bestmove without movement.")
+				return true
+			}
+			result.BestMove = fields[1]
+			if len(fields) >= 4 && fields[2] == "ponder" {
+				result.Ponder = fields[3]
+			}
+			return true
+		}
+
+		return false
+	})
+	if err != nil {
+		if readCtx.Err() != nil {
+			return result, ctx.Err()
+		}
+		return result, err
+	}
+
+	return result, parseErr
+}
+
+// Stop sends the "stop" command, to end the running search as soon as possible.
+func (e *Engine) Stop() error {
+	return e.send("stop")
+}
+
+// PonderHit sends the "ponderhit" command, when the opponent played the
+// movement the engine was pondering on.
+func (e *Engine) PonderHit() error {
+	return e.send("ponderhit")
+}
+
+func (r *SearchResult) addInfo(info Info) {
+	multiPV := max(info.MultiPV, 1)
+	for len(r.Infos) < multiPV {
+		r.Infos = append(r.Infos, Info{})
+	}
+	r.Infos[multiPV-1] = info
+}
+
+func (e *Engine) send(command string) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	_, err := io.WriteString(e.w, command+"\n")
+	return err
+}
+
+func (e *Engine) readLines(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e.lines <- scanner.Text()
+	}
+
+	e.readErr = scanner.Err()
+	if e.readErr == nil {
+		e.readErr = io.EOF
+	}
+	close(e.lines)
+}
+
+// readUntil reads lines from the engine, until done returns true.
+func (e *Engine) readUntil(ctx context.Context, done func(line string) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-e.lines:
+			if !ok {
+				return fmt.Errorf("The engine connection was closed: %w", e.readErr)
+			}
+			if done(line) {
+				return nil
+			}
+		}
+	}
+}
+
+// parseOption parses the fields of an "option" line, after "option".
+func parseOption(fields []string) (Option, bool) {
+	var option Option
+
+	keywords := map[string]bool{"name": true, "type": true, "default": true, "min": true, "max": true, "var": true}
+	for i := 0; i < len(fields); {
+		keyword := fields[i]
+		j := i + 1
+		for j < len(fields) && !keywords[fields[j]] {
+			j++
+		}
+		value := strings.Join(fields[i+1:j], " ")
+
+		switch keyword {
+		case "name":
+			option.Name = value
+		case "type":
+			option.Type = value
+		case "default":
+			option.Default = value
+		case "min":
+			option.Min, _ = strconv.Atoi(value)
+		case "max":
+			option.Max, _ = strconv.Atoi(value)
+		case "var":
+			option.Vars = append(option.Vars, value)
+		}
+
+		i = j
+	}
+
+	return option, option.Name != ""
+}
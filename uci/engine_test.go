@@ -0,0 +1,222 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// fakeEngine answers the UCI commands it receives, recording them.
+type fakeEngine struct {
+	commands   []string
+	infinite   chan struct{} // Closed once an infinite search starts
+	ignoreStop bool          // Whether to never answer "stop"
+}
+
+func newFakeEngine(ignoreStop bool) (*Engine, *fakeEngine) {
+	clientReader, engineWriter := io.Pipe()
+	engineReader, clientWriter := io.Pipe()
+
+	fake := &fakeEngine{infinite: make(chan struct{}), ignoreStop: ignoreStop}
+	go fake.run(engineReader, engineWriter)
+
+	return New(struct {
+		io.Reader
+		io.Writer
+	}{clientReader, clientWriter}), fake
+}
+
+func (f *fakeEngine) run(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		command := scanner.Text()
+		f.commands = append(f.commands, command)
+
+		switch {
+		case command == "uci":
+			fmt.Fprintln(w, "id name Fake Engine 1.0")
+			fmt.Fprintln(w, "id author Someone")
+			fmt.Fprintln(w, "option name Hash type spin default 16 min 1 max 1024")
+			fmt.Fprintln(w, "option name Clear Hash type button")
+			fmt.Fprintln(w, "option name Style type combo default Normal var Solid var Normal var Risky")
+			fmt.Fprintln(w, "uciok")
+		case command == "isready":
+			fmt.Fprintln(w, "readyok")
+		case command == "go infinite":
+			fmt.Fprintln(w, "info depth 1 score cp 10 pv e2e4")
+			close(f.infinite)
+		case command == "go depth 1":
+			fmt.Fprintln(w, "info depth one pv e2e4")
+			fmt.Fprintln(w, "info depth 1 score cp 20 pv d2d4")
+			fmt.Fprintln(w, "bestmove d2d4")
+		case strings.HasPrefix(command, "go"):
+			fmt.Fprintln(w, "info string searching")
+			fmt.Fprintln(w, "info depth 10 seldepth 14 multipv 1 score cp 35 nodes 120000 nps 600000 time 200 pv e2e4 e7e5 g1f3")
+			fmt.Fprintln(w, "info depth 10 seldepth 12 multipv 2 score mate -3 upperbound nodes 120000 pv d2d4 d7d5")
+			fmt.Fprintln(w, "bestmove e2e4 ponder e7e5")
+		case command == "stop" && !f.ignoreStop:
+			fmt.Fprintln(w, "bestmove e2e4")
+		case command == "quit":
+			return
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	engine, fake := newFakeEngine(false)
+	ctx := context.Background()
+
+	if err := engine.UCI(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if engine.Name != "Fake Engine 1.0" || engine.Author != "Someone" {
+		t.Errorf("Expected the engine's identification, got %q by %q", engine.Name, engine.Author)
+	}
+	if hash := engine.Options["Hash"]; hash.Type != "spin" || hash.Default != "16" || hash.Max != 1024 {
+		t.Errorf("Expected the Hash option, got %+v", hash)
+	}
+	if style := engine.Options["Style"]; len(style.Vars) != 3 || style.Default != "Normal" {
+		t.Errorf("Expected the Style option, got %+v", style)
+	}
+	if _, ok := engine.Options["Clear Hash"]; !ok {
+		t.Errorf("Expected options with spaces in their name")
+	}
+
+	engine.SetOption("Hash", "64")
+	engine.SetOption("Clear Hash", "")
+	if err := engine.IsReady(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	game, _ := chess.NewGame("")
+	game.MakeMovementAlgebraic("d2d4")
+	game.MakeMovementAlgebraic("d7d5")
+	engine.Position(&game)
+
+	var infos []Info
+	result, err := engine.Go(ctx, GoParams{Depth: 10, MoveTime: 2 * time.Second}, func(info Info) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(infos) != 3 || infos[0].String != "searching" {
+		t.Errorf("Expected 3 infos, got %+v", infos)
+	}
+	if result.BestMove != "e2e4" || result.Ponder != "e7e5" || len(result.Infos) != 2 || result.Infos[1].PV[0] != "d2d4" {
+		t.Errorf("Unexpected search result %+v", result)
+	}
+
+	expectedCommands := []string{
+		"uci",
+		"setoption name Hash value 64",
+		"setoption name Clear Hash",
+		"isready",
+		"position startpos moves d2d4 d7d5",
+		"go movetime 2000 depth 10",
+	}
+	for i, command := range expectedCommands {
+		if fake.commands[i] != command {
+			t.Errorf("Expected command %q, got %q", command, fake.commands[i])
+		}
+	}
+
+	// Cancelling the context stops the search
+	searchCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-fake.infinite
+		cancel()
+	}()
+	result, err = engine.Go(searchCtx, GoParams{Infinite: true}, nil)
+	if err != nil || result.BestMove != "e2e4" {
+		t.Errorf("Expected the stopped search's best movement, got %+v (%v)", result, err)
+	}
+
+	// Malformed info lines are skipped
+	result, err = engine.Go(ctx, GoParams{Depth: 1}, nil)
+	if err != nil || result.BestMove != "d2d4" || len(result.Infos) != 1 {
+		t.Errorf("Expected the malformed info to be skipped, got %+v (%v)", result, err)
+	}
+
+	if err := engine.Close(); err != nil {
+		t.Errorf("Unexpected error closing the engine: %s", err)
+	}
+}
+
+func TestEngineStopTimeout(t *testing.T) {
+	engine, fake := newFakeEngine(true)
+	engine.StopTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-fake.infinite
+		cancel()
+	}()
+
+	// The engine never answers "stop"
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.Go(ctx, GoParams{Infinite: true}, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected the context's error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the search to end after the stop timeout")
+	}
+
+	engine.Close()
+}
+
+func TestParseInfo(t *testing.T) {
+	info, err := ParseInfo("info depth 20 seldepth 28 multipv 2 score mate -4 lowerbound nodes 1234567 nps 987654 hashfull 512 tbhits 3 time 1250 pv e2e4 e7e5 currmove e2e4 currmovenumber 1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expectedScore := Score{Mate: -4, IsMate: true, LowerBound: true}
+	if info.Depth != 20 || info.SelDepth != 28 || info.MultiPV != 2 || !info.HasScore || info.Score != expectedScore ||
+		info.Nodes != 1234567 || info.NPS != 987654 || info.HashFull != 512 || info.TBHits != 3 ||
+		info.Time != 1250*time.Millisecond || len(info.PV) != 2 || info.CurrMove != "e2e4" || info.CurrMoveNumber != 1 {
+		t.Errorf("Unexpected info %+v", info)
+	}
+
+	for _, line := range []string{"bestmove e2e4", "info depth x", "info score cp", "info score wdl 1 2 3"} {
+		if _, err := ParseInfo(line); err == nil {
+			t.Errorf("Expected an error parsing %q", line)
+		}
+	}
+}
+
+func TestParseMovements(t *testing.T) {
+	game, _ := chess.NewGame("")
+
+	movements, err := Info{PV: []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "e1g1"}}.PVMovements(&game)
+	if err != nil || len(movements) != 7 || !movements[6].IsKingSideCastling() {
+		t.Errorf("Expected 7 movements ending in castling, got %v (%v)", movements, err)
+	}
+
+	movements, err = ParseMovements(game.CurrentPosition(), []string{"e2e4", "e2e4"})
+	if err == nil || len(movements) != 1 {
+		t.Errorf("Expected an error on the second movement, got %v (%v)", movements, err)
+	}
+
+	if _, err := (SearchResult{BestMove: "(none)"}).BestMovement(&game); err == nil {
+		t.Errorf("Expected an error without best movement")
+	}
+	if movement, err := (SearchResult{BestMove: "g1f3"}).BestMovement(&game); err != nil || movement.Algebraic() != "g1f3" {
+		t.Errorf("Expected g1f3, got %v (%v)", movement, err)
+	}
+}
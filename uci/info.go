@@ -0,0 +1,207 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// Score represents the evaluation of a position, from the engine's
+// point of view (positive is better for the player to move).
+type Score struct {
+	Centipawns int  // Only if IsMate is not set
+	Mate       int  // Movements (not plies) to mate, negative if the engine is getting mated
+	IsMate     bool // Whether the score is a mate score
+	LowerBound bool // The score is only a lower bound
+	UpperBound bool // The score is only an upper bound
+}
+
+// Info represents the data of an "info" line sent by the engine during a
+// search. Fields that were not sent have their zero value.
+type Info struct {
+	Depth          int
+	SelDepth       int
+	MultiPV        int // 1 for the best line. 0 if not sent
+	Score          Score
+	HasScore       bool
+	Nodes          uint64
+	NPS            uint64
+	Time           time.Duration
+	HashFull       int // In permill
+	TBHits         uint64
+	PV             []string // Principal variation, in Pure algebraic notation
+	CurrMove       string
+	CurrMoveNumber int
+	String         string // Free text sent by the engine
+}
+
+// Keywords of an "info" line, used to know where the PV ends.
+var infoKeywords = map[string]bool{
+	"depth": true, "seldepth": true, "multipv": true, "score": true, "nodes": true, "nps": true,
+	"time": true, "hashfull": true, "tbhits": true, "pv": true, "currmove": true,
+	"currmovenumber": true, "string": true, "cpuload": true, "refutation": true, "currline": true,
+}
+
+// ParseInfo parses an "info" line sent by a UCI engine.
+//
+// Example:
+//
+//	ParseInfo("info depth 20 seldepth 28 multipv 1 score cp 35 nodes 1234567 nps 987654 time 1250 pv e2e4 e7e5")
+func ParseInfo(line string) (Info, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return Info{}, errors.New("The line is not an info line.")
+	}
+
+	var info Info
+	for i := 1; i < len(fields); i++ {
+		keyword := fields[i]
+
+		// Keywords followed by a single value
+		if keyword != "score" && keyword != "pv" && keyword != "string" && infoKeywords[keyword] {
+			if i+1 >= len(fields) {
+				return Info{}, fmt.Errorf("The info %s has no value.", keyword)
+			}
+			i++
+		}
+		value := fields[i]
+
+		var err error
+		switch keyword {
+		case "depth":
+			info.Depth, err = strconv.Atoi(value)
+		case "seldepth":
+			info.SelDepth, err = strconv.Atoi(value)
+		case "multipv":
+			info.MultiPV, err = strconv.Atoi(value)
+		case "nodes":
+			info.Nodes, err = strconv.ParseUint(value, 10, 64)
+		case "nps":
+			info.NPS, err = strconv.ParseUint(value, 10, 64)
+		case "tbhits":
+			info.TBHits, err = strconv.ParseUint(value, 10, 64)
+		case "hashfull":
+			info.HashFull, err = strconv.Atoi(value)
+		case "currmovenumber":
+			info.CurrMoveNumber, err = strconv.Atoi(value)
+		case "currmove":
+			info.CurrMove = value
+		case "time":
+			var milliseconds int64
+			milliseconds, err = strconv.ParseInt(value, 10, 64)
+			info.Time = time.Duration(milliseconds) * time.Millisecond
+		case "score":
+			i, err = parseScore(fields, i+1, &info.Score)
+			info.HasScore = err == nil
+		case "pv":
+			for i+1 < len(fields) && !infoKeywords[fields[i+1]] {
+				i++
+				info.PV = append(info.PV, fields[i])
+			}
+		case "string":
+			info.String = strings.Join(fields[i+1:], " ")
+			i = len(fields)
+		}
+
+		if err != nil {
+			return Info{}, fmt.Errorf("The info %s has an invalid value: %w", keyword, err)
+		}
+	}
+
+	return info, nil
+}
+
+// parseScore parses the score fields starting at i, and returns the index
+// of the last field of the score.
+func parseScore(fields []string, i int, score *Score) (int, error) {
+	if i+1 >= len(fields) {
+		return i, errors.New("The score is incomplete.")
+	}
+
+	value, err := strconv.Atoi(fields[i+1])
+	if err != nil {
+		return i, err
+	}
+
+	switch fields[i] {
+	case "cp":
+		score.Centipawns = value
+	case "mate":
+		score.Mate = value
+		score.IsMate = true
+	default:
+		return i, fmt.Errorf("The score type \"%s\" is not valid.", fields[i])
+	}
+	i++
+
+	if i+1 < len(fields) {
+		switch fields[i+1] {
+		case "lowerbound":
+			score.LowerBound = true
+			i++
+		case "upperbound":
+			score.UpperBound = true
+			i++
+		}
+	}
+
+	return i, nil
+}
+
+// ParseMovement returns the legal movement of the position written in Pure
+// algebraic notation, as sent by UCI engines.
+//
+// If the movement is not legal in the position, it will return an empty
+// Movement and the error.
+func ParseMovement(position chess.Position, movement string) (chess.Movement, error) {
+	for _, legalMovement := range position.LegalMovements() {
+		if legalMovement.Algebraic() == movement {
+			return legalMovement, nil
+		}
+	}
+	return chess.Movement{}, fmt.Errorf("The movement \"%s\" is not legal in the position \"%s\".", movement, position.Fen())
+}
+
+// ParseMovements returns the legal movements of a sequence of movements in
+// Pure algebraic notation (such as a PV), made one after another from
+// the passed position.
+//
+// If any movement is not legal, it will return the movements parsed until
+// then, and the error.
+func ParseMovements(position chess.Position, movements []string) ([]chess.Movement, error) {
+	parsed := make([]chess.Movement, 0, len(movements))
+	for _, movement := range movements {
+		legalMovement, err := ParseMovement(position, movement)
+		if err != nil {
+			return parsed, err
+		}
+
+		parsed = append(parsed, legalMovement)
+		position = position.Apply(legalMovement)
+	}
+	return parsed, nil
+}
+
+// BestMovement returns the best movement of the search, as a Movement of the
+// game's current position.
+//
+// If the engine found no legal movements, it will return an empty Movement and the error.
+func (r SearchResult) BestMovement(game *chess.Game) (chess.Movement, error) {
+	if r.BestMove == "" || r.BestMove == "(none)" || r.BestMove == "0000" {
+		return chess.Movement{}, errors.New("The engine did not find any movement.")
+	}
+	return ParseMovement(game.CurrentPosition(), r.BestMove)
+}
+
+// PVMovements returns the principal variation of the info, as Movements
+// made one after another from the game's current position.
+//
+// If any movement is not legal, it will return the movements parsed until
+// then, and the error.
+func (i Info) PVMovements(game *chess.Game) ([]chess.Movement, error) {
+	return ParseMovements(game.CurrentPosition(), i.PV)
+}
@@ -0,0 +1,481 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// Searcher represents the search engine behind a Server.
+type Searcher interface {
+	// Search searches the best movement of the game's current position with
+	// the passed parameters, calling onInfo for each info to send to the GUI.
+	//
+	// It must return once the context is done. Infinite and pondering
+	// searches are only ended that way.
+	Search(ctx context.Context, game *chess.Game, params GoParams, onInfo func(Info)) (SearchResult, error)
+}
+
+// Configurable is implemented by searchers that have options, which are
+// sent to the GUI and set via "setoption".
+type Configurable interface {
+	Options() []Option
+	SetOption(name, value string) error
+}
+
+// NewGamer is implemented by searchers that need to be told about
+// "ucinewgame", for example, to clear their hash tables.
+type NewGamer interface {
+	NewGame()
+}
+
+const startingFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// Server represents the UCI front-end of a Searcher, that lets it be used as
+// an engine by any UCI GUI or match runner.
+//
+// The "UCI_Chess960" option is handled by the Server itself.
+//
+// Note: If you intend in creating a new Server, use NewServer() function.
+type Server struct {
+	name     string
+	author   string
+	searcher Searcher
+
+	w       io.Writer
+	writeMu sync.Mutex
+
+	game       *chess.Game
+	isChess960 bool
+
+	search *serverSearch // The running search, if any
+}
+
+// serverSearch represents a search running in the background.
+type serverSearch struct {
+	cancel    context.CancelFunc
+	ponderHit chan struct{} // Closed on "ponderhit"
+	done      chan struct{} // Closed once "bestmove" has been sent
+}
+
+// NewServer creates and returns a new Server, identified with the passed
+// name and author, that searches with the passed Searcher.
+func NewServer(name, author string, searcher Searcher) *Server {
+	game, _ := chess.NewGame(startingFen)
+	return &Server{
+		name:     name,
+		author:   author,
+		searcher: searcher,
+		game:     &game,
+	}
+}
+
+// Serve reads UCI commands from r, and writes the answers to w, until
+// "quit" is received or r ends. Searches run in the background, so
+// "stop", "ponderhit" and "isready" are answered while searching.
+//
+// Invalid commands are ignored, and errors are reported as "info string".
+//
+// Example:
+//
+//	server := uci.NewServer("My Engine", "Me", searcher)
+//	server.Serve(os.Stdin, os.Stdout)
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.stopSearch()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "uci":
+			s.sendIdentification()
+		case "isready":
+			s.send("readyok")
+		case "setoption":
+			s.stopSearch()
+			err = s.setOption(fields[1:])
+		case "ucinewgame":
+			s.stopSearch()
+			if newGamer, ok := s.searcher.(NewGamer); ok {
+				newGamer.NewGame()
+			}
+			err = s.setPosition([]string{"startpos"})
+		case "position":
+			s.stopSearch()
+			err = s.setPosition(fields[1:])
+		case "go":
+			s.stopSearch()
+			var params GoParams
+			if params, err = ParseGoParams(scanner.Text()); err == nil {
+				s.startSearch(params)
+			}
+		case "stop":
+			s.stopSearch()
+		case "ponderhit":
+			if s.search != nil {
+				select {
+				case <-s.search.ponderHit:
+				default:
+					close(s.search.ponderHit)
+				}
+			}
+		case "quit":
+			return nil
+		}
+
+		if err != nil {
+			s.send("info string " + err.Error())
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Server) sendIdentification() {
+	s.send("id name " + s.name)
+	s.send("id author " + s.author)
+
+	s.send(Option{Name: "UCI_Chess960", Type: "check", Default: "false"}.String())
+	if configurable, ok := s.searcher.(Configurable); ok {
+		for _, option := range configurable.Options() {
+			s.send(option.String())
+		}
+	}
+
+	s.send("uciok")
+}
+
+// setOption handles the fields of a "setoption" command, after "setoption".
+func (s *Server) setOption(fields []string) error {
+	var name, value []string
+	var target *[]string
+	for _, field := range fields {
+		switch {
+		case field == "name" && target == nil:
+			target = &name
+		case field == "value" && target == &name:
+			target = &value
+		case target != nil:
+			*target = append(*target, field)
+		}
+	}
+
+	if len(name) == 0 {
+		return errors.New("The setoption command has no option name.")
+	}
+
+	if strings.Join(name, " ") == "UCI_Chess960" {
+		s.isChess960 = strings.Join(value, " ") == "true"
+		return nil
+	}
+
+	if configurable, ok := s.searcher.(Configurable); ok {
+		return configurable.SetOption(strings.Join(name, " "), strings.Join(value, " "))
+	}
+	return fmt.Errorf("The option \"%s\" does not exist.", strings.Join(name, " "))
+}
+
+// setPosition handles the fields of a "position" command, after "position".
+func (s *Server) setPosition(fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("The position command has no position.")
+	}
+
+	fen := startingFen
+	var movements []string
+
+	switch fields[0] {
+	case "startpos":
+		fields = fields[1:]
+	case "fen":
+		i := 1
+		for i < len(fields) && fields[i] != "moves" {
+			i++
+		}
+		fen = strings.Join(fields[1:i], " ")
+		fields = fields[i:]
+	default:
+		return fmt.Errorf("The position \"%s\" is not valid.", fields[0])
+	}
+
+	if len(fields) > 0 && fields[0] == "moves" {
+		movements = fields[1:]
+	}
+
+	newGame := chess.NewGame
+	if s.isChess960 {
+		newGame = chess.NewChess960GameFromFen
+	}
+	game, err := newGame(fen)
+	if err != nil {
+		return err
+	}
+
+	for _, movement := range movements {
+		if err := game.MakeMovementAlgebraic(movement); err != nil {
+			return fmt.Errorf("The movement \"%s\" is not valid: %w", movement, err)
+		}
+	}
+
+	// Positions are never modified, only replaced, so searches can keep using theirs
+	s.game = &game
+	return nil
+}
+
+// startSearch starts searching the current position in the background.
+//
+// Pondering searches are restarted as normal ones on "ponderhit". The best
+// movement of pondering and infinite searches is held until they are
+// stopped (or until "ponderhit", for pondering searches).
+func (s *Server) startSearch(params GoParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	search := &serverSearch{
+		cancel:    cancel,
+		ponderHit: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	s.search = search
+	game := s.game
+
+	go func() {
+		defer close(search.done)
+
+		if params.Ponder {
+			ponderCtx, cancelPonder := context.WithCancel(ctx)
+			go func() {
+				select {
+				case <-search.ponderHit:
+					cancelPonder()
+				case <-ponderCtx.Done():
+				}
+			}()
+
+			result, err := s.searcher.Search(ponderCtx, game, params, s.sendInfo)
+
+			select {
+			case <-search.ponderHit:
+			case <-ctx.Done():
+			}
+			cancelPonder()
+
+			if ctx.Err() != nil {
+				s.sendBestMove(result, err)
+				return
+			}
+			params.Ponder = false
+		}
+
+		result, err := s.searcher.Search(ctx, game, params, s.sendInfo)
+		if params.Infinite {
+			<-ctx.Done()
+		}
+		s.sendBestMove(result, err)
+	}()
+}
+
+// stopSearch stops the running search, if any, and waits until its best
+// movement has been sent.
+func (s *Server) stopSearch() {
+	if s.search == nil {
+		return
+	}
+
+	s.search.cancel()
+	<-s.search.done
+	s.search = nil
+}
+
+func (s *Server) sendInfo(info Info) {
+	s.send(info.line())
+}
+
+func (s *Server) sendBestMove(result SearchResult, err error) {
+	if err != nil {
+		s.send("info string " + err.Error())
+	}
+
+	if result.BestMove == "" {
+		result.BestMove = "(none)"
+	}
+
+	if result.Ponder != "" {
+		s.send("bestmove " + result.BestMove + " ponder " + result.Ponder)
+	} else {
+		s.send("bestmove " + result.BestMove)
+	}
+}
+
+func (s *Server) send(line string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	io.WriteString(s.w, line+"\n")
+}
+
+// ParseGoParams parses a "go" command sent by a GUI.
+//
+// Example:
+//
+//	ParseGoParams("go wtime 300000 btime 300000 winc 2000 binc 2000") // returns GoParams{WTime: 5 * time.Minute, ...}
+func ParseGoParams(line string) (GoParams, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "go" {
+		return GoParams{}, errors.New("The line is not a go command.")
+	}
+
+	var params GoParams
+	for i := 1; i < len(fields); i++ {
+		keyword := fields[i]
+
+		switch keyword {
+		case "infinite":
+			params.Infinite = true
+			continue
+		case "ponder":
+			params.Ponder = true
+			continue
+		case "searchmoves":
+			for i+1 < len(fields) && isMovement(fields[i+1]) {
+				i++
+				params.SearchMoves = append(params.SearchMoves, fields[i])
+			}
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return GoParams{}, fmt.Errorf("The go parameter %s has no value.", keyword)
+		}
+		i++
+
+		value, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			// Some GUIs send negative times when the clock runs out
+			if signed, signedErr := strconv.ParseInt(fields[i], 10, 64); signedErr == nil && signed < 0 {
+				value, err = 0, nil
+			} else {
+				return GoParams{}, fmt.Errorf("The go parameter %s has an invalid value: %w", keyword, err)
+			}
+		}
+		milliseconds := time.Duration(value) * time.Millisecond
+
+		switch keyword {
+		case "wtime":
+			params.WTime = milliseconds
+		case "btime":
+			params.BTime = milliseconds
+		case "winc":
+			params.WInc = milliseconds
+		case "binc":
+			params.BInc = milliseconds
+		case "movetime":
+			params.MoveTime = milliseconds
+		case "movestogo":
+			params.MovesToGo = int(value)
+		case "depth":
+			params.Depth = int(value)
+		case "nodes":
+			params.Nodes = value
+		case "mate":
+			params.Mate = int(value)
+		}
+	}
+
+	return params, nil
+}
+
+// isMovement reports whether the field looks like a movement in Pure
+// algebraic notation, rather than a go parameter.
+func isMovement(field string) bool {
+	return (len(field) == 4 || len(field) == 5) && field[0] >= 'a' && field[0] <= 'h' && field[1] >= '1' && field[1] <= '8'
+}
+
+func (o Option) String() string {
+	var sb strings.Builder
+	sb.WriteString("option name " + o.Name + " type " + o.Type)
+
+	if o.Type != "button" {
+		sb.WriteString(" default " + o.Default)
+	}
+	if o.Type == "spin" {
+		sb.WriteString(" min " + strconv.Itoa(o.Min) + " max " + strconv.Itoa(o.Max))
+	}
+	for _, v := range o.Vars {
+		sb.WriteString(" var " + v)
+	}
+
+	return sb.String()
+}
+
+// line returns the info as an "info" line.
+func (i Info) line() string {
+	var sb strings.Builder
+	sb.WriteString("info")
+
+	integers := []struct {
+		name  string
+		value uint64
+	}{{"depth", uint64(i.Depth)}, {"seldepth", uint64(i.SelDepth)}, {"multipv", uint64(i.MultiPV)}}
+	for _, integer := range integers {
+		if integer.value > 0 {
+			sb.WriteString(" " + integer.name + " " + strconv.FormatUint(integer.value, 10))
+		}
+	}
+
+	if i.HasScore {
+		if i.Score.IsMate {
+			sb.WriteString(" score mate " + strconv.Itoa(i.Score.Mate))
+		} else {
+			sb.WriteString(" score cp " + strconv.Itoa(i.Score.Centipawns))
+		}
+
+		if i.Score.LowerBound {
+			sb.WriteString(" lowerbound")
+		} else if i.Score.UpperBound {
+			sb.WriteString(" upperbound")
+		}
+	}
+
+	integers = []struct {
+		name  string
+		value uint64
+	}{{"nodes", i.Nodes}, {"nps", i.NPS}, {"hashfull", uint64(i.HashFull)}, {"tbhits", i.TBHits}}
+	for _, integer := range integers {
+		if integer.value > 0 {
+			sb.WriteString(" " + integer.name + " " + strconv.FormatUint(integer.value, 10))
+		}
+	}
+	if i.Time > 0 || i.Nodes > 0 {
+		sb.WriteString(" time " + strconv.FormatInt(i.Time.Milliseconds(), 10))
+	}
+
+	if i.CurrMove != "" {
+		sb.WriteString(" currmove " + i.CurrMove)
+	}
+	if i.CurrMoveNumber > 0 {
+		sb.WriteString(" currmovenumber " + strconv.Itoa(i.CurrMoveNumber))
+	}
+	if len(i.PV) > 0 {
+		sb.WriteString(" pv " + strings.Join(i.PV, " "))
+	}
+
+	// The string takes the rest of the line, so it goes last
+	if i.String != "" {
+		sb.WriteString(" string " + i.String)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package uci
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/keelus/chess"
+)
+
+// fakeSearcher plays the first legal movement, recording its searches.
+type fakeSearcher struct {
+	fens       []string
+	params     []GoParams
+	isChess960 bool
+	options    map[string]string
+	newGames   int
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, game *chess.Game, params GoParams, onInfo func(Info)) (SearchResult, error) {
+	f.fens = append(f.fens, game.CurrentFen())
+	f.params = append(f.params, params)
+	f.isChess960 = game.CurrentPosition().IsChess960()
+
+	best := game.LegalMovements()[0].Algebraic()
+	onInfo(Info{Depth: 1, Score: Score{Centipawns: 12}, HasScore: true, Nodes: 20, Time: time.Millisecond, PV: []string{best}})
+
+	if params.Infinite || params.Ponder {
+		<-ctx.Done()
+	}
+	return SearchResult{BestMove: best}, nil
+}
+
+func (f *fakeSearcher) Options() []Option {
+	return []Option{{Name: "Skill Level", Type: "spin", Default: "20", Min: 0, Max: 20}}
+}
+
+func (f *fakeSearcher) SetOption(name, value string) error {
+	f.options[name] = value
+	return nil
+}
+
+func (f *fakeSearcher) NewGame() {
+	f.newGames++
+}
+
+func TestServer(t *testing.T) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	searcher := &fakeSearcher{options: make(map[string]string)}
+	served := make(chan error)
+	go func() {
+		served <- NewServer("Fake Server", "Someone", searcher).Serve(serverReader, serverWriter)
+	}()
+
+	// The server is driven through the package's own client
+	engine := New(struct {
+		io.Reader
+		io.Writer
+	}{clientReader, clientWriter})
+	ctx := context.Background()
+
+	if err := engine.UCI(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if engine.Name != "Fake Server" || engine.Author != "Someone" {
+		t.Errorf("Expected the server's identification, got %q by %q", engine.Name, engine.Author)
+	}
+	if option := engine.Options["Skill Level"]; option.Type != "spin" || option.Max != 20 {
+		t.Errorf("Expected the searcher's options, got %+v", engine.Options)
+	}
+
+	engine.SetOption("Skill Level", "5")
+	engine.NewGame()
+	if err := engine.IsReady(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if searcher.options["Skill Level"] != "5" || searcher.newGames != 1 {
+		t.Errorf("Expected the option to be set and a new game, got %v and %d", searcher.options, searcher.newGames)
+	}
+
+	game, _ := chess.NewGame("")
+	game.MakeMovementAlgebraic("e2e4")
+	game.MakeMovementAlgebraic("e7e5")
+	engine.Position(&game)
+
+	var infos []Info
+	result, err := engine.Go(ctx, GoParams{Depth: 3, WTime: time.Minute}, func(info Info) {
+		infos = append(infos, info)
+	})
+	if err != nil || result.BestMove != game.LegalMovements()[0].Algebraic() || len(infos) != 1 || infos[0].Score.Centipawns != 12 {
+		t.Errorf("Unexpected search result %+v (%v), with infos %+v", result, err, infos)
+	}
+	if searcher.fens[0] != game.CurrentFen() || searcher.params[0].Depth != 3 || searcher.params[0].WTime != time.Minute {
+		t.Errorf("Expected the search of %q, got %q with %+v", game.CurrentFen(), searcher.fens[0], searcher.params[0])
+	}
+
+	// Infinite searches are only ended by "stop"
+	searchCtx, cancel := context.WithCancel(ctx)
+	result, err = engine.Go(searchCtx, GoParams{Infinite: true}, func(Info) {
+		engine.IsReady(ctx) // Answered while searching
+		cancel()
+	})
+	if err != nil || result.BestMove == "" {
+		t.Errorf("Expected the stopped search's best movement, got %+v (%v)", result, err)
+	}
+
+	// Pondering searches are restarted as normal ones on "ponderhit"
+	result, err = engine.Go(ctx, GoParams{Ponder: true, MoveTime: time.Second}, func(info Info) {
+		engine.PonderHit()
+	})
+	if last := searcher.params[len(searcher.params)-1]; err != nil || len(searcher.params) != 4 || last.Ponder || last.MoveTime != time.Second {
+		t.Errorf("Expected the search to restart after ponderhit, got %+v (%v)", searcher.params, err)
+	}
+
+	engine.SetOption("UCI_Chess960", "true")
+	chess960Game, _ := chess.NewChess960Game(0)
+	engine.Position(&chess960Game)
+	if _, err := engine.Go(ctx, GoParams{Depth: 1}, nil); err != nil || !searcher.isChess960 {
+		t.Errorf("Expected a Chess960 search, got %v", err)
+	}
+
+	if err := engine.Close(); err != nil {
+		t.Errorf("Unexpected error closing the engine: %s", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected the server to end on quit, got %s", err)
+	}
+}
+
+func TestServerPosition(t *testing.T) {
+	server := NewServer("Fake Server", "Someone", &fakeSearcher{})
+
+	if err := server.setPosition([]string{"fen", "8/8/8/8/8/8/8/8", "w", "-", "-", "0", "1", "moves", "e2e4"}); err == nil {
+		t.Errorf("Expected an error with an invalid FEN")
+	}
+	if err := server.setPosition([]string{"startpos", "moves", "e2e4", "e2e4"}); err == nil {
+		t.Errorf("Expected an error with an illegal movement")
+	}
+
+	if err := server.setPosition([]string{"fen", "7k/8/8/8/8/8/8/K7", "w", "-", "-", "0", "1", "moves", "a1b1", "h8g8"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if server.game.CurrentFen() != "6k1/8/8/8/8/8/8/1K6 w - - 2 2" {
+		t.Errorf("Expected the position after the movements, got %q", server.game.CurrentFen())
+	}
+}
+
+func TestParseGoParams(t *testing.T) {
+	params, err := ParseGoParams("go searchmoves e2e4 d2d4 ponder wtime 300000 btime -20 winc 2000 binc 2000 movestogo 40 depth 8 nodes 5000 mate 3 infinite")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(params.SearchMoves) != 2 || !params.Ponder || params.WTime != 5*time.Minute || params.BTime != 0 ||
+		params.WInc != 2*time.Second || params.BInc != 2*time.Second || params.MovesToGo != 40 ||
+		params.Depth != 8 || params.Nodes != 5000 || params.Mate != 3 || !params.Infinite {
+		t.Errorf("Unexpected params %+v", params)
+	}
+
+	// Parsing the sent command must give the same params
+	if parsed, err := ParseGoParams(params.String()); err != nil || parsed.String() != params.String() {
+		t.Errorf("Expected %q to round trip, got %q (%v)", params.String(), parsed.String(), err)
+	}
+
+	for _, line := range []string{"stop", "go depth", "go nodes x"} {
+		if _, err := ParseGoParams(line); err == nil {
+			t.Errorf("Expected an error parsing %q", line)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestPositionHash(t *testing.T) {
+	// Keys from the Polyglot opening book format specification
+	tests := []struct {
+		movements []string
+		hash      uint64
+	}{
+		{[]string{}, 0x463B96181691FC9C},
+		{[]string{"e2e4"}, 0x823C9B50FD114196},
+		{[]string{"e2e4", "d7d5"}, 0x0756B94461C50FB0},
+		{[]string{"e2e4", "d7d5", "e4e5"}, 0x662FAFB965DB29D4},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5"}, 0x22A48B5A8E47FF78},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, 0x652A607CA3F242C1},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, 0x00FDD303C946BDD9},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, 0x3C8123EA7B067637},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, 0x5C3F9B829B279560},
+	}
+
+	for _, test := range tests {
+		game, _ := NewGame("")
+		for _, movement := range test.movements {
+			if err := game.MakeMovementAlgebraic(movement); err != nil {
+				t.Fatalf("Unexpected error making %s: %s", movement, err)
+			}
+		}
+
+		if hash := game.CurrentPosition().Hash(); hash != test.hash {
+			t.Errorf("Expected hash %016X after %v, got %016X", test.hash, test.movements, hash)
+		}
+
+		// The incremental hash must match the one computed from scratch
+		fromFen, _ := NewGame(game.CurrentFen())
+		if fromFen.CurrentPosition().Hash() != test.hash {
+			t.Errorf("Expected hash %016X from %q, got %016X", test.hash, game.CurrentFen(), fromFen.CurrentPosition().Hash())
+		}
+	}
+}
+
+func TestPositionHashMakeUnmake(t *testing.T) {
+	game, _ := NewGame("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	position := game.CurrentPosition()
+
+	var walk func(depth int)
+	walk = func(depth int) {
+		if depth == 0 {
+			return
+		}
+
+		hash := position.Hash()
+		for _, movement := range position.LegalMovements() {
+			undo := position.makeMovement(movement)
+
+			fromFen, _ := NewGame(position.Fen())
+			if position.Hash() != fromFen.CurrentPosition().Hash() {
+				t.Fatalf("Expected the hash of %q after %s to match the one from its FEN", position.Fen(), movement.Algebraic())
+			}
+			walk(depth - 1)
+
+			position.unmakeMovement(movement, undo)
+			if position.Hash() != hash {
+				t.Fatalf("Expected unmaking %s to restore the hash", movement.Algebraic())
+			}
+		}
+	}
+
+	walk(2)
+}